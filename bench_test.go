@@ -212,3 +212,30 @@ func BenchmarkDiff(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkShadowList measures listing the shadows of a key that keeps many
+// alternatives, which reads the lines above the row once however many there
+// are.
+func BenchmarkShadowList(b *testing.B) {
+	for _, n := range benchSizes {
+		var src strings.Builder
+		for i := range n {
+			fmt.Fprintf(&src, "# DB_URL=postgres://replica-%d # @profile=p%d\n", i, i)
+		}
+		src.WriteString("DB_URL=postgres://primary\n")
+		e, err := envi.ParseString(src.String())
+		if err != nil {
+			b.Fatal(err)
+		}
+		r := e.Get("DB_URL")
+
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if len(r.ShadowList()) != n {
+					b.Fatal("lost a shadow")
+				}
+			}
+		})
+	}
+}
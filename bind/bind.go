@@ -4,13 +4,12 @@ import (
 	"errors"
 	"os"
 	"reflect"
-	"strings"
 
 	envi "github.com/efureev/envi/v2"
 )
 
-// A Source supplies values by key. [*envi.Env] satisfies it, and so does
-// anything else a caller already has.
+// A Source supplies values by key. [*envi.Env] and [*envi.Layers] satisfy it,
+// and so does anything else a caller already has.
 type Source interface {
 	// Lookup returns the value stored under key and whether it was present.
 	// Keys arrive normalised, in the upper-case form [envi.NormalizeKey]
@@ -52,17 +51,18 @@ func Decode(src Source, dst any, opts ...Option) error {
 
 // gather assembles the source described by cfg: the files in order, then the
 // process environment on top when asked for.
+//
+// The files are stacked as [envi.Layers] rather than merged, so nothing is
+// copied and precedence is applied per key as the struct asks for it.
 func gather(cfg *config) (Source, error) {
-	env := envi.New()
+	layers := &envi.Layers{}
 
 	for _, path := range cfg.files {
 		e, err := envi.Load(path)
 		if err != nil {
 			return nil, err
 		}
-		if err := env.Merge(e); err != nil {
-			return nil, err
-		}
+		layers.Push(path, e)
 	}
 	for _, path := range cfg.optionalFiles {
 		e, err := envi.Load(path)
@@ -72,19 +72,10 @@ func gather(cfg *config) (Source, error) {
 		if err != nil {
 			return nil, err
 		}
-		if err := env.Merge(e); err != nil {
-			return nil, err
-		}
+		layers.Push(path, e)
 	}
 
-	if cfg.environ {
-		for _, kv := range os.Environ() {
-			if k, v, ok := strings.Cut(kv, "="); ok {
-				env.Set(k, v)
-			}
-		}
-	}
-	return env, nil
+	return layers.SetEnviron(cfg.environ), nil
 }
 
 func decode(src Source, dst any, cfg *config) error {
@@ -117,7 +108,8 @@ func decode(src Source, dst any, cfg *config) error {
 		}
 		// An empty value counts as absent: a key present but blank states that
 		// nothing was configured, not that the field should be blanked.
-		if !ok || value == "" {
+		stored := ok && value != ""
+		if !stored {
 			switch {
 			case f.hasDef:
 				value = f.def
@@ -129,6 +121,15 @@ func decode(src Source, dst any, cfg *config) error {
 			}
 		}
 
+		if stored && cfg.decryptor != nil {
+			plain, err := cfg.decryptor.Decrypt(key, value)
+			if err != nil {
+				failures = append(failures, FieldError{Field: f.name, Key: key, Err: err})
+				continue
+			}
+			value = plain
+		}
+
 		if err := f.set(fieldByIndex(rv, f.index), value); err != nil {
 			failures = append(failures, FieldError{Field: f.name, Key: key, Err: err})
 		}
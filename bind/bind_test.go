@@ -12,6 +12,7 @@ import (
 
 	envi "github.com/efureev/envi/v2"
 	"github.com/efureev/envi/v2/bind"
+	"github.com/efureev/envi/v2/crypt"
 )
 
 // mapSource is a Source built from a literal, showing that the package works
@@ -519,3 +520,45 @@ func TestLoadFilesAndEnviron(t *testing.T) {
 		}
 	})
 }
+
+// Layers is what Load builds, and a caller holding one can decode from it
+// directly.
+var _ bind.Source = (*envi.Layers)(nil)
+
+func TestWithDecryptor(t *testing.T) {
+	t.Parallel()
+
+	k, err := crypt.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := k.Encrypt("DB_PASSWORD", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved, err := k.Encrypt("OTHER", "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type Config struct {
+		Host     string `env:"DB_HOST"`
+		Password string `env:"DB_PASSWORD"`
+		Port     int    `env:"DB_PORT,default=5432"`
+	}
+
+	var cfg Config
+	src := mapSource{"DB_HOST": "localhost", "DB_PASSWORD": enc}
+	if err := bind.Decode(src, &cfg, bind.WithDecryptor(k)); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if cfg.Host != "localhost" || cfg.Password != "hunter2" || cfg.Port != 5432 {
+		t.Errorf("cfg = %+v", cfg)
+	}
+
+	err = bind.Decode(mapSource{"DB_PASSWORD": moved}, &cfg, bind.WithDecryptor(k))
+	var fe *bind.FieldError
+	if !errors.As(err, &fe) || fe.Key != "DB_PASSWORD" || !errors.Is(err, crypt.ErrDecrypt) {
+		t.Errorf("a value moved between keys: err = %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package bind
+
+import (
+	"reflect"
+	"strings"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// A FieldInfo describes one key a configuration struct reads, as [Describe]
+// reports it.
+type FieldInfo struct {
+	// Key is the key the field reads, with [WithPrefix] applied.
+	Key string `json:"key"`
+
+	// Field is the path to the field in Go terms, such as "DB.Port".
+	Field string `json:"field"`
+
+	// Type is the field's Go type, such as "time.Duration" or "[]string".
+	Type string `json:"type"`
+
+	// Default is the value used when the key is not set, and HasDefault says
+	// whether there is one, since an empty default is a default too.
+	Default    string `json:"default,omitempty"`
+	HasDefault bool   `json:"-"`
+
+	// Required says whether a missing value is an error: the tag says so, or
+	// [WithRequiredByDefault] does for a field without a default.
+	Required bool `json:"required"`
+
+	// Description is the field's desc tag.
+	Description string `json:"description,omitempty"`
+}
+
+// Describe reports every key a configuration struct reads, in field order, for
+// building documentation or a JSON description of a service's settings.
+//
+// v may be a struct, a pointer to one, or a nil pointer of the struct's type:
+// only the type is looked at. A field's description comes from a desc tag next
+// to its env tag:
+//
+//	type Config struct {
+//	    Port int `env:"PORT,default=8080" desc:"port the server listens on"`
+//	}
+func Describe(v any, opts ...Option) ([]FieldInfo, error) {
+	cfg := newConfig(opts)
+
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, ErrNotStruct
+	}
+
+	p, err := planFor(t, cfg.tagName, cfg.converters)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]FieldInfo, len(p.fields))
+	for i := range p.fields {
+		f := &p.fields[i]
+		key := f.key
+		if cfg.prefix != "" {
+			key = envi.NormalizeKey(joinKey(cfg.prefix, key))
+		}
+		out[i] = FieldInfo{
+			Key:         key,
+			Field:       f.name,
+			Type:        f.typ.String(),
+			Default:     f.def,
+			HasDefault:  f.hasDef,
+			Required:    f.req || (cfg.requireAll && !f.hasDef),
+			Description: f.desc,
+		}
+	}
+	return out, nil
+}
+
+// Example returns a document listing every key a configuration struct reads,
+// for writing out as the .env.example a service ships with:
+//
+//	envi.Save(bind.Example((*Config)(nil)), ".env.example")
+//
+// Each key carries its default as the value, or is left empty, and its
+// description as the comment above it, followed by a line saying so when the
+// key is required. The fields of a nested struct are gathered into a block,
+// whose header is the nested field's own description. Generated from the
+// struct, the example cannot drift from it.
+//
+// v is taken as [Describe] takes it. Example panics where Describe would
+// return an error — v is not a struct, or one of its fields has a type this
+// package cannot fill — since for a type known when the program is written
+// that is a mistake in the program. Call Describe first to handle it as an
+// error instead.
+func Example(v any, opts ...Option) *envi.Env {
+	fields, err := Describe(v, opts...)
+	if err != nil {
+		panic("bind: Example: " + err.Error())
+	}
+
+	cfg := newConfig(opts)
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// Describe has just built this plan, so it comes from the cache or, with
+	// converters registered, is built again without fail.
+	p, _ := planFor(t, cfg.tagName, cfg.converters)
+
+	env := envi.New()
+	for i, info := range fields {
+		f := &p.fields[i]
+
+		row := envi.NewRow(info.Key, info.Default)
+		var comment []string
+		if info.Description != "" {
+			comment = append(comment, info.Description)
+		}
+		if info.Required {
+			comment = append(comment, "required")
+		}
+		row.SetComment(strings.Join(comment, "\n"))
+
+		if len(f.index) > 1 {
+			if prefix, _, ok := strings.Cut(info.Key, "_"); ok && env.Block(prefix) == nil {
+				// The row's prefix matches by construction, so Add cannot fail.
+				_ = env.Add(envi.NewBlock(prefix).SetComment(f.group))
+			}
+		}
+		// Adding a row, unlike a block, cannot fail.
+		_ = env.Add(row)
+	}
+	return env
+}
@@ -0,0 +1,79 @@
+package bind_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/efureev/envi/v2/bind"
+)
+
+type describedDB struct {
+	Host string `env:"HOST,default=localhost" desc:"database host"`
+	Port int    `env:"PORT,required"`
+}
+
+type describedConfig struct {
+	Name string        `env:"APP_NAME" desc:"human readable name"`
+	TTL  time.Duration `env:"CACHE_TTL,default=30s"`
+	DB   describedDB   `env:"DB" desc:"Database"`
+}
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	got, err := bind.Describe((*describedConfig)(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []bind.FieldInfo{
+		{Key: "APP_NAME", Field: "Name", Type: "string", Description: "human readable name"},
+		{Key: "CACHE_TTL", Field: "TTL", Type: "time.Duration", Default: "30s", HasDefault: true},
+		{Key: "DB_HOST", Field: "DB.Host", Type: "string", Default: "localhost", HasDefault: true, Description: "database host"},
+		{Key: "DB_PORT", Field: "DB.Port", Type: "int", Required: true},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Describe =\n%+v\nwant\n%+v", got, want)
+	}
+
+	got, err = bind.Describe(describedConfig{}, bind.WithPrefix("SVC"), bind.WithRequiredByDefault())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Key != "SVC_APP_NAME" || !got[0].Required || got[1].Required {
+		t.Errorf("with a prefix and required by default: %+v", got[:2])
+	}
+
+	if _, err := bind.Describe(42); !errors.Is(err, bind.ErrNotStruct) {
+		t.Errorf("Describe(42) err = %v", err)
+	}
+	if _, err := bind.Describe(nil); !errors.Is(err, bind.ErrNotStruct) {
+		t.Errorf("Describe(nil) err = %v", err)
+	}
+}
+
+func TestExample(t *testing.T) {
+	t.Parallel()
+
+	env := bind.Example(&describedConfig{})
+	want := "# human readable name\nAPP_NAME=\nCACHE_TTL=30s\n" +
+		"###   ---[ Database ]---   ###\n# database host\nDB_HOST=localhost\n# required\nDB_PORT=\n"
+	if got := env.String(); got != want {
+		t.Errorf("Example wrote\n%s\nwant\n%s", got, want)
+	}
+	if b := env.Block("DB"); b == nil || b.Len() != 2 {
+		t.Errorf("the nested struct did not become a block of two rows")
+	}
+}
+
+func TestExamplePanicsOnAMistake(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Example of a non-struct did not panic")
+		}
+	}()
+	bind.Example(42)
+}
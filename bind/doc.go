@@ -40,6 +40,10 @@
 //   - separator=… — what divides the elements of a slice or map, "," by default
 //   - a tag of "-" skips the field
 //
+// A desc tag beside the env tag describes the field. Binding ignores it;
+// [Describe] and [Example] carry it into documentation and a generated
+// .env.example.
+//
 // # Types
 //
 // Strings, booleans, every sized integer and float, [time.Duration], slices and
@@ -71,6 +75,25 @@
 // is an [*Error]; [errors.Is] and [errors.As] see through it to the individual
 // causes, including [ErrRequired].
 //
+// # Writing back
+//
+// [Marshal] goes the other way, from a struct to an [envi.Env] that
+// [envi.Save] can write: the same keys, each value in the form it would be read
+// back in, and nested structs gathered into blocks. A type that needs
+// [WithConverter] to be read needs [WithFormatter] to be written.
+//
+// # Reloading
+//
+// [Watch] keeps a configuration current while a program runs: it polls the
+// files and swaps in a freshly bound value when they change. A reload that
+// fails leaves the last good value in place and is reported instead.
+//
+// # Encrypted values
+//
+// A file whose values were encrypted with the envi/crypt package is read with
+// [WithDecryptor], which opens each value before it is converted. Values that
+// are not encrypted pass through, so one file can mix the two.
+//
 // # Precedence and absence
 //
 // Files are read in the order given, each overriding the one before, and the
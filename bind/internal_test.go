@@ -5,10 +5,8 @@ package bind
 // bind_test.
 
 import (
-	"errors"
 	"reflect"
 	"testing"
-	"time"
 )
 
 func TestJoinKey(t *testing.T) {
@@ -58,24 +56,6 @@ func TestItoa(t *testing.T) {
 	}
 }
 
-// setViaText refuses a value it cannot take the address of, rather than
-// panicking inside reflect. Struct fields are always addressable, so this
-// guard is unreachable through the exported API — which is exactly why it is
-// worth pinning here.
-func TestSetViaTextRejectsUnaddressableValue(t *testing.T) {
-	t.Parallel()
-
-	unaddressable := reflect.ValueOf(time.Time{})
-	if unaddressable.CanAddr() {
-		t.Fatal("precondition: the value must not be addressable")
-	}
-
-	err := setViaText(unaddressable, "2026-08-12T10:00:00Z")
-	if !errors.Is(err, ErrUnsupportedType) {
-		t.Errorf("error = %v, want ErrUnsupportedType", err)
-	}
-}
-
 // The plan cache must hand back the same plan for a repeated type rather than
 // rebuilding it, which is what keeps binding allocation-free after warm-up.
 func TestPlanCacheReturnsTheSameInstance(t *testing.T) {
@@ -0,0 +1,316 @@
+package bind
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// A formatter writes one field as text. It is the reverse of a [setter], and
+// [Marshal] chooses it the way [converterFor] chooses setters.
+type formatter func(v reflect.Value) (string, error)
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+// Marshal writes a configuration struct out as a document, the reverse of
+// [Decode]. v may be a struct or a non-nil pointer to one.
+//
+// Keys are the ones [Decode] would read, tag names, [WithTagName] and
+// [WithPrefix] included, and each value is written in the form its setter
+// reads back: through [encoding.TextMarshaler], a [time.Duration] in its
+// String form, slice elements and map entries joined by the field's
+// separator. A type neither this package nor the type itself knows how to
+// write needs [WithFormatter].
+//
+// A field a nil pointer hides has nothing to say and is left out, as is
+// everything in a nested struct reached through one. The fields of a nested
+// struct are gathered into a [envi.Block] by their prefix, so the document
+// reads the way the struct is organised:
+//
+//	env, err := bind.Marshal(&cfg)
+//	err = envi.Save(env, ".env")
+//
+// Every field that cannot be written is reported, in an [*Error]; a slice
+// element or map entry holding the separator is one, since it could not be
+// told apart from two on the way back in.
+func Marshal(v any, opts ...Option) (*envi.Env, error) {
+	cfg := newConfig(opts)
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, ErrNotPointer
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrNotStruct
+	}
+	if !rv.CanAddr() {
+		// A struct passed by value cannot lend its fields' addresses to a
+		// pointer-receiver MarshalText; a copy can.
+		c := reflect.New(rv.Type()).Elem()
+		c.Set(rv)
+		rv = c
+	}
+
+	p, err := planFor(rv.Type(), cfg.tagName, marshalConverters(&cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	env := envi.New()
+	var failures []FieldError
+	for i := range p.fields {
+		f := &p.fields[i]
+
+		key := f.key
+		if cfg.prefix != "" {
+			key = envi.NormalizeKey(joinKey(cfg.prefix, key))
+		}
+
+		fv, ok := reachField(rv, f.index)
+		if !ok {
+			continue
+		}
+		format, err := formatterFor(f.typ, f.sep, cfg.formatters)
+		if err != nil {
+			failures = append(failures, FieldError{Field: f.name, Key: key, Err: err})
+			continue
+		}
+		value, err := format(fv)
+		if errors.Is(err, errNilValue) {
+			continue
+		}
+		if err != nil {
+			failures = append(failures, FieldError{Field: f.name, Key: key, Err: err})
+			continue
+		}
+
+		if len(f.index) > 1 {
+			// A field of a nested struct belongs in a block. Adding the block
+			// first is what lets the row find it.
+			if prefix, _, ok := strings.Cut(key, "_"); ok && env.Block(prefix) == nil {
+				if err := env.Add(envi.NewBlock(prefix)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := env.Add(envi.NewRow(key, value)); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(failures) > 0 {
+		return nil, &Error{Fields: failures}
+	}
+	return env, nil
+}
+
+// marshalConverters returns what the plan must treat as a single value when
+// writing: every type with a converter, as when reading, and every type with a
+// formatter as well, which would otherwise be taken apart into its fields. A
+// formatted type with no converter gets a setter that says so; Marshal never
+// calls it.
+func marshalConverters(cfg *config) map[reflect.Type]setter {
+	if len(cfg.formatters) == 0 {
+		return cfg.converters
+	}
+	conv := maps.Clone(cfg.converters)
+	if conv == nil {
+		conv = make(map[reflect.Type]setter, len(cfg.formatters))
+	}
+	for t := range cfg.formatters {
+		if _, ok := conv[t]; !ok {
+			conv[t] = func(reflect.Value, string) error {
+				return fmt.Errorf("%w: %s has a formatter but no converter", ErrUnsupportedType, t)
+			}
+		}
+	}
+	return conv
+}
+
+// reachField walks to a field without allocating anything on the way, and
+// reports false when a nil pointer stands between the struct and the field.
+func reachField(v reflect.Value, index []int) (reflect.Value, bool) {
+	v = v.Field(index[0])
+	for _, i := range index[1:] {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
+// errNilValue is what a formatter reports for a nil pointer, which has no value
+// to write.
+var errNilValue = errors.New("bind: nil value")
+
+// formatterFor returns the formatter for a field type, or an error wrapping
+// [ErrUnsupportedType]. The checks come in the order [converterFor] makes them,
+// so that a field is written in the form it will be read back in.
+func formatterFor(t reflect.Type, sep string, fmts map[reflect.Type]formatter) (formatter, error) {
+	if format, ok := fmts[t]; ok {
+		return format, nil
+	}
+
+	// Reading goes through UnmarshalText whenever the type has it, so writing
+	// goes through MarshalText. A type that reads itself but cannot write
+	// itself falls through to its kind, which is the best left to try.
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		switch {
+		case t.Implements(textMarshalerType):
+			return formatViaText, nil
+		case reflect.PointerTo(t).Implements(textMarshalerType):
+			return formatViaTextAddr, nil
+		}
+	}
+	if t == durationType {
+		return func(v reflect.Value) (string, error) {
+			return time.Duration(v.Int()).String(), nil
+		}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return func(v reflect.Value) (string, error) { return v.String(), nil }, nil
+
+	case reflect.Bool:
+		return func(v reflect.Value) (string, error) { return strconv.FormatBool(v.Bool()), nil }, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value) (string, error) { return strconv.FormatInt(v.Int(), 10), nil }, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(v reflect.Value) (string, error) { return strconv.FormatUint(v.Uint(), 10), nil }, nil
+
+	case reflect.Float32, reflect.Float64:
+		bits := t.Bits()
+		return func(v reflect.Value) (string, error) {
+			return strconv.FormatFloat(v.Float(), 'g', -1, bits), nil
+		}, nil
+
+	case reflect.Pointer:
+		inner, err := formatterFor(t.Elem(), sep, fmts)
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) (string, error) {
+			if v.IsNil() {
+				return "", errNilValue
+			}
+			return inner(v.Elem())
+		}, nil
+
+	case reflect.Slice:
+		return sliceFormatter(t, sep, fmts)
+
+	case reflect.Map:
+		return mapFormatter(t, sep, fmts)
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+	}
+}
+
+// sliceFormatter builds the formatter for a slice field: the elements joined by
+// the separator, or the text itself for []byte.
+func sliceFormatter(t reflect.Type, sep string, fmts map[reflect.Type]formatter) (formatter, error) {
+	if t.Elem().Kind() == reflect.Uint8 {
+		return func(v reflect.Value) (string, error) { return string(v.Bytes()), nil }, nil
+	}
+
+	elem, err := formatterFor(t.Elem(), sep, fmts)
+	if err != nil {
+		return nil, err
+	}
+	return func(v reflect.Value) (string, error) {
+		if v.IsNil() {
+			return "", errNilValue
+		}
+		parts := make([]string, v.Len())
+		for i := range parts {
+			s, err := elem(v.Index(i))
+			if errors.Is(err, errNilValue) {
+				return "", fmt.Errorf("element %d is nil", i)
+			}
+			if err != nil {
+				return "", fmt.Errorf("element %d: %w", i, err)
+			}
+			if strings.Contains(s, sep) {
+				return "", fmt.Errorf("element %d: %q holds the separator %q", i, s, sep)
+			}
+			parts[i] = s
+		}
+		return strings.Join(parts, sep), nil
+	}, nil
+}
+
+// mapFormatter builds the formatter for a map field: key:value entries joined
+// by the separator, sorted so that writing the same map twice gives the same
+// text.
+func mapFormatter(t reflect.Type, sep string, fmts map[reflect.Type]formatter) (formatter, error) {
+	keyFmt, err := formatterFor(t.Key(), sep, fmts)
+	if err != nil {
+		return nil, err
+	}
+	valFmt, err := formatterFor(t.Elem(), sep, fmts)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v reflect.Value) (string, error) {
+		if v.IsNil() {
+			return "", errNilValue
+		}
+		entries := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k, err := keyFmt(iter.Key())
+			if err != nil {
+				return "", fmt.Errorf("key: %w", err)
+			}
+			if strings.Contains(k, ":") {
+				return "", fmt.Errorf("key %q holds ':'", k)
+			}
+			val, err := valFmt(iter.Value())
+			if errors.Is(err, errNilValue) {
+				return "", fmt.Errorf("value for %q is nil", k)
+			}
+			if err != nil {
+				return "", fmt.Errorf("value for %q: %w", k, err)
+			}
+			entry := k + ":" + val
+			if strings.Contains(entry, sep) {
+				return "", fmt.Errorf("entry %q holds the separator %q", entry, sep)
+			}
+			entries = append(entries, entry)
+		}
+		slices.Sort(entries)
+		return strings.Join(entries, sep), nil
+	}, nil
+}
+
+func formatViaText(v reflect.Value) (string, error) {
+	b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	return string(b), err
+}
+
+func formatViaTextAddr(v reflect.Value) (string, error) {
+	if !v.CanAddr() {
+		return "", fmt.Errorf("%w: %s is not addressable", ErrUnsupportedType, v.Type())
+	}
+	return formatViaText(v.Addr())
+}
@@ -0,0 +1,179 @@
+package bind_test
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	envi "github.com/efureev/envi/v2"
+	"github.com/efureev/envi/v2/bind"
+)
+
+func TestMarshalRoundTrips(t *testing.T) {
+	t.Parallel()
+
+	type DB struct {
+		Host string
+		Port int
+	}
+	type Config struct {
+		Name    string `env:"APP_NAME"`
+		Debug   bool
+		Ratio   float64
+		TTL     time.Duration
+		Started time.Time
+		IP      net.IP
+		Hosts   []string          `env:"HOSTS,separator=;"`
+		Limits  map[string]int    `env:"LIMITS"`
+		Labels  map[string]string `env:"LABELS"`
+		Raw     []byte
+		DB      DB     `env:"DB"`
+		Cache   *DB    `env:"CACHE"`
+		Skipped string `env:"-"`
+	}
+
+	in := Config{
+		Name:    "app",
+		Debug:   true,
+		Ratio:   0.25,
+		TTL:     90 * time.Second,
+		Started: time.Date(2026, 8, 12, 10, 0, 0, 0, time.UTC),
+		IP:      net.ParseIP("10.0.0.1"),
+		Hosts:   []string{"a", "b"},
+		Limits:  map[string]int{"b": 2, "a": 1},
+		Raw:     []byte("raw"),
+		DB:      DB{Host: "localhost", Port: 5432},
+		Skipped: "never",
+	}
+
+	env, err := bind.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "APP_NAME=app\nDEBUG=true\nRATIO=0.25\nTTL=1m30s\nSTARTED=2026-08-12T10:00:00Z\nIP=10.0.0.1\n" +
+		"HOSTS=a;b\nLIMITS=a:1,b:2\nRAW=raw\n" +
+		"DB_HOST=localhost\nDB_PORT=5432\n"
+	if got := env.String(); got != want {
+		t.Errorf("Marshal wrote\n%s\nwant\n%s", got, want)
+	}
+	if env.Block("DB") == nil {
+		t.Error("the nested struct did not become a block")
+	}
+
+	var out Config
+	if err := bind.Decode(env, &out); err != nil {
+		t.Fatal(err)
+	}
+	in.Skipped = ""
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+}
+
+func TestMarshalPrefixAndPointers(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Port *int
+		Name string
+	}
+	port := 80
+
+	env, err := bind.Marshal(&Config{Port: &port, Name: "x"}, bind.WithPrefix("APP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := env.String(), "APP_PORT=80\nAPP_NAME=x\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// A nil pointer has nothing to say.
+	env, err = bind.Marshal(&Config{Name: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Has("PORT") {
+		t.Error("a nil pointer field was written")
+	}
+}
+
+func TestMarshalFormatter(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Endpoint *url.URL `env:"ENDPOINT"`
+	}
+	u, _ := url.Parse("https://api.example.com/v1")
+	formatURL := bind.WithFormatter(func(u *url.URL) (string, error) { return u.String(), nil })
+
+	env, err := bind.Marshal(Config{Endpoint: u}, formatURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := env.Lookup("ENDPOINT"); got != "https://api.example.com/v1" {
+		t.Errorf("ENDPOINT = %q", got)
+	}
+	// Without a formatter the type is taken apart like any struct, and its
+	// parts are written instead, which is what a formatter is for.
+	env, err = bind.Marshal(Config{Endpoint: u})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Has("ENDPOINT") || !env.Has("ENDPOINT_HOST") {
+		t.Errorf("without a formatter wrote %q", env.String())
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Hosts []string `env:"HOSTS"`
+		Ch    chan int `env:"-"`
+	}
+
+	_, err := bind.Marshal(Config{Hosts: []string{"a,b"}})
+	var be *bind.Error
+	if !errors.As(err, &be) || len(be.Fields) != 1 || be.Fields[0].Key != "HOSTS" {
+		t.Errorf("element holding the separator: err = %v", err)
+	}
+
+	if _, err := bind.Marshal((*Config)(nil)); !errors.Is(err, bind.ErrNotPointer) {
+		t.Errorf("nil pointer: err = %v", err)
+	}
+	if _, err := bind.Marshal(42); !errors.Is(err, bind.ErrNotStruct) {
+		t.Errorf("not a struct: err = %v", err)
+	}
+
+	type point struct{ X, Y int }
+	type WithConv struct {
+		P point `env:"P"`
+	}
+	parse := bind.WithConverter(func(string) (point, error) { return point{}, nil })
+	if _, err := bind.Marshal(WithConv{}, parse); !errors.Is(err, bind.ErrUnsupportedType) {
+		t.Errorf("a converter without a formatter: err = %v", err)
+	}
+}
+
+// Marshal's output is a document like any other and can be saved.
+func TestMarshalIsADocument(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Name string `env:"APP_NAME"`
+	}
+	env, err := bind.Marshal(Config{Name: "has space"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := env.String(), "APP_NAME=has space\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := envi.ParseString(env.String()); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,10 +1,17 @@
 package bind
 
-import "reflect"
+import (
+	"reflect"
+	"time"
+)
 
 // defaultTagName is the struct tag this package reads.
 const defaultTagName = "env"
 
+// descTagName is the struct tag [Describe] and [Example] take a field's
+// description from. It is read alongside the env tag, whatever that is named.
+const descTagName = "desc"
+
 // defaultSeparator splits the elements of a slice or map value.
 const defaultSeparator = ","
 
@@ -24,6 +31,16 @@ type config struct {
 	// do not use it: a nil map is what tells planFor it may use the cache.
 	converters map[reflect.Type]setter
 
+	// formatters holds the functions registered with [WithFormatter], the
+	// reverse of converters, for [Marshal].
+	formatters map[reflect.Type]formatter
+
+	// pollInterval is how often [Watch] polls, zero for its default.
+	pollInterval time.Duration
+
+	// decryptor, when set, opens every value before it is converted.
+	decryptor Decryptor
+
 	environ    bool
 	requireAll bool
 }
@@ -89,6 +106,35 @@ func WithTagName(name string) Option {
 	})
 }
 
+// WithPollInterval sets how often [Watch] looks at the files for changes. Zero
+// or less keeps the default of one second.
+//
+// Used by [Watch] only.
+func WithPollInterval(d time.Duration) Option {
+	return optionFunc(func(c *config) { c.pollInterval = d })
+}
+
+// A Decryptor turns a value as stored into the value to use. The *Key of the
+// envi/crypt package is one.
+type Decryptor interface {
+	// Decrypt returns the plaintext of value, stored under key. It is handed
+	// every value read, and returns one it does not recognise as encrypted
+	// unchanged.
+	Decrypt(key, value string) (string, error)
+}
+
+// WithDecryptor decrypts values as they are read, so that a service can load a
+// committed file whose values are encrypted without a step of its own:
+//
+//	k, err := crypt.ReadKeyFile("/run/secrets/env.key")
+//	err = bind.Load(&cfg, bind.WithFiles(".env.production"), bind.WithDecryptor(k))
+//
+// A value that does not decrypt fails its field like one that does not convert.
+// Defaults from tags are used as written.
+func WithDecryptor(d Decryptor) Option {
+	return optionFunc(func(c *config) { c.decryptor = d })
+}
+
 // WithRequiredByDefault treats every field without a default as required,
 // turning a missing value into an error instead of a zero field.
 func WithRequiredByDefault() Option {
@@ -158,3 +204,37 @@ func WithConverter[T any](fn func(string) (T, error)) Option {
 		c.converters[t] = set
 	})
 }
+
+// WithFormatter registers fn as the way to write a value of type T, for
+// [Marshal]. It is the reverse of [WithConverter], and a type that needs one
+// usually needs the other:
+//
+//	bind.Marshal(&cfg,
+//	    bind.WithConverter(url.Parse),
+//	    bind.WithFormatter(func(u *url.URL) (string, error) { return u.String(), nil }))
+//
+// A registered type wins over everything else, including its own
+// [encoding.TextMarshaler], and is written as one value rather than taken apart
+// as a struct. As with converters, registering the value type covers pointers
+// to it and slices and maps of it. Calls accumulate, the last registration for
+// a type wins, and a nil fn registers nothing.
+func WithFormatter[T any](fn func(T) (string, error)) Option {
+	if fn == nil {
+		return nil
+	}
+
+	t := reflect.TypeFor[T]()
+	format := func(v reflect.Value) (string, error) {
+		// The comma-ok form hands an interface T holding nil over as nil
+		// rather than panicking.
+		in, _ := v.Interface().(T)
+		return fn(in)
+	}
+
+	return optionFunc(func(c *config) {
+		if c.formatters == nil {
+			c.formatters = make(map[reflect.Type]formatter, 4)
+		}
+		c.formatters[t] = format
+	})
+}
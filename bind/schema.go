@@ -19,6 +19,16 @@ type field struct {
 	hasDef bool
 	req    bool
 	set    setter
+
+	// typ and sep are what [Marshal] needs to write the field back: the
+	// setter runs one way only.
+	typ reflect.Type
+	sep string
+
+	// desc is the field's desc tag, and group that of the nested struct field
+	// it sits in, if any. Only [Describe] and [Example] read them.
+	desc  string
+	group string
 }
 
 // A plan is everything binding needs to know about a struct type.
@@ -48,7 +58,7 @@ var planCache sync.Map // planKey -> *plan or error
 func planFor(t reflect.Type, tag string, conv map[reflect.Type]setter) (*plan, error) {
 	if len(conv) > 0 {
 		p := &plan{}
-		if err := appendFields(p, t, tag, nil, "", "", map[reflect.Type]bool{t: true}, conv); err != nil {
+		if err := appendFields(p, t, tag, nil, "", "", "", map[reflect.Type]bool{t: true}, conv); err != nil {
 			return nil, err
 		}
 		return p, nil
@@ -65,7 +75,7 @@ func planFor(t reflect.Type, tag string, conv map[reflect.Type]setter) (*plan, e
 	}
 
 	p := &plan{}
-	err := appendFields(p, t, tag, nil, "", "", map[reflect.Type]bool{t: true}, nil)
+	err := appendFields(p, t, tag, nil, "", "", "", map[reflect.Type]bool{t: true}, nil)
 	if err != nil {
 		planCache.Store(k, err)
 		return nil, err
@@ -75,8 +85,9 @@ func planFor(t reflect.Type, tag string, conv map[reflect.Type]setter) (*plan, e
 }
 
 // appendFields walks a struct type, descending into nested structs and
-// recording a leaf for every field it can fill.
-func appendFields(p *plan, t reflect.Type, tag string, index []int, namePrefix, keyPrefix string, seen map[reflect.Type]bool, conv map[reflect.Type]setter) error {
+// recording a leaf for every field it can fill. group is the description of
+// the outermost nested struct being walked, empty at top level.
+func appendFields(p *plan, t reflect.Type, tag string, index []int, namePrefix, keyPrefix, group string, seen map[reflect.Type]bool, conv map[reflect.Type]setter) error {
 	for i := range t.NumField() {
 		sf := t.Field(i)
 		if !sf.IsExported() {
@@ -101,7 +112,13 @@ func appendFields(p *plan, t reflect.Type, tag string, index []int, namePrefix,
 			if opts.name != "" {
 				childKeys = joinKey(keyPrefix, opts.name)
 			}
-			if err := appendFields(p, nested, tag, idx, goName+".", childKeys, seen, conv); err != nil {
+			// A block is named by the first segment of its keys, so the
+			// outermost nested struct is the one that introduces it.
+			childGroup := group
+			if childGroup == "" {
+				childGroup = sf.Tag.Get(descTagName)
+			}
+			if err := appendFields(p, nested, tag, idx, goName+".", childKeys, childGroup, seen, conv); err != nil {
 				return err
 			}
 
@@ -127,6 +144,10 @@ func appendFields(p *plan, t reflect.Type, tag string, index []int, namePrefix,
 			hasDef: opts.hasDef,
 			req:    opts.req,
 			set:    set,
+			typ:    sf.Type,
+			sep:    opts.sep,
+			desc:   sf.Tag.Get(descTagName),
+			group:  group,
 		})
 	}
 	return nil
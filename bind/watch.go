@@ -0,0 +1,90 @@
+package bind
+
+import (
+	"context"
+	"sync/atomic"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// A Watcher holds the configuration [Watch] keeps current.
+type Watcher[T any] struct {
+	cur atomic.Pointer[T]
+}
+
+// Current returns the configuration as last read successfully. It never
+// returns nil, and the value it returns is never modified afterwards: a
+// reload builds a new one and swaps it in, so a caller may hold on to it for
+// as long as it needs one consistent view.
+func (w *Watcher[T]) Current() *T { return w.cur.Load() }
+
+// Watch fills a T the way [Load] does, and fills a new one whenever the files
+// change, until ctx is done.
+//
+//	w, err := bind.Watch[Config](ctx, func(err error) { log.Print(err) },
+//	    bind.WithFiles(".env"), bind.WithEnviron())
+//	...
+//	cfg := w.Current()
+//
+// T must be a struct type. The first read happens before Watch returns, and
+// its failure is returned: there is no good value to keep yet. After that a
+// reload that fails — a file that no longer parses, or a value that no longer
+// binds — leaves the last good value in place and is handed to onError, which
+// may be nil. A binding failure arrives as an [*Error], the same one [Load]
+// would have returned; a read failure as whatever reading reported.
+//
+// The files are polled, by [envi.Watch], every [WithPollInterval]. The process
+// environment is consulted afresh on every reload but not watched: a program
+// cannot change another's environment, only its own.
+func Watch[T any](ctx context.Context, onError func(error), opts ...Option) (*Watcher[T], error) {
+	cfg := newConfig(opts)
+
+	// A first value that does not bind ends the watch as well: nobody is
+	// left to cancel it.
+	ctx, cancel := context.WithCancel(ctx)
+	updates, err := envi.Watch(ctx, cfg.files, envi.WatchOptions{
+		Interval: cfg.pollInterval,
+		Optional: cfg.optionalFiles,
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	w := &Watcher[T]{}
+	first := <-updates
+	v, err := decodeUpdate[T](first.Env, &cfg)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	w.cur.Store(v)
+
+	go func() {
+		defer cancel()
+		for u := range updates {
+			if u.Err == nil {
+				var v *T
+				if v, u.Err = decodeUpdate[T](u.Env, &cfg); u.Err == nil {
+					w.cur.Store(v)
+					continue
+				}
+			}
+			if onError != nil {
+				onError(u.Err)
+			}
+		}
+	}()
+	return w, nil
+}
+
+// decodeUpdate fills a fresh T from one reloaded document, with the process
+// environment on top when the configuration asks for it.
+func decodeUpdate[T any](env *envi.Env, cfg *config) (*T, error) {
+	layers := (&envi.Layers{}).Push("", env).SetEnviron(cfg.environ)
+	v := new(T)
+	if err := decode(layers, v, cfg); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
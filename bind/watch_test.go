@@ -0,0 +1,105 @@
+package bind_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/efureev/envi/v2/bind"
+)
+
+func TestWatchKeepsTheLastGoodValue(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Name string `env:"APP_NAME"`
+		Port int    `env:"APP_PORT,required"`
+	}
+
+	path := filepath.Join(t.TempDir(), ".env")
+	write := func(content string, stamp int) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		at := time.Now().Add(time.Duration(stamp) * time.Minute)
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("APP_NAME=one\nAPP_PORT=1\n", 0)
+
+	failures := make(chan error, 4)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	w, err := bind.Watch[config](ctx, func(err error) { failures <- err },
+		bind.WithFiles(path), bind.WithPollInterval(5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := *w.Current(); got != (config{Name: "one", Port: 1}) {
+		t.Fatalf("initial value = %+v", got)
+	}
+
+	write("APP_NAME=two\nAPP_PORT=2\n", 1)
+	waitFor(t, func() bool { return w.Current().Port == 2 })
+	if got := *w.Current(); got != (config{Name: "two", Port: 2}) {
+		t.Errorf("after a reload = %+v", got)
+	}
+
+	// A value that no longer binds is reported and the good one kept.
+	write("APP_NAME=three\nAPP_PORT=x\n", 2)
+	select {
+	case err := <-failures:
+		var be *bind.Error
+		if !errors.As(err, &be) {
+			t.Errorf("failure = %v, want a *bind.Error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the failed reload was not reported")
+	}
+	if got := *w.Current(); got != (config{Name: "two", Port: 2}) {
+		t.Errorf("after a failed reload = %+v, want the last good value", got)
+	}
+}
+
+func TestWatchFailsWhenTheFirstValueDoesNotBind(t *testing.T) {
+	type config struct {
+		Port int `env:"APP_PORT,required"`
+	}
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("OTHER=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := bind.Watch[config](context.Background(), nil, bind.WithFiles(path))
+	if !errors.Is(err, bind.ErrRequired) {
+		t.Errorf("err = %v, want ErrRequired", err)
+	}
+
+	// Nothing is left polling the file, though the context never ends. The
+	// test is not parallel, so no other watch is running to be seen.
+	waitFor(t, func() bool {
+		buf := make([]byte, 1<<20)
+		return !strings.Contains(string(buf[:runtime.Stack(buf, true)]), "created by github.com/efureev/envi/v2.Watch")
+	})
+}
+
+// waitFor polls cond until it holds, failing the test after a few seconds.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition never held")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
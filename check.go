@@ -59,11 +59,33 @@ const (
 	// RuleEmptyValue reports a live row whose value is empty.
 	RuleEmptyValue Rule = "empty-value"
 
-	// RuleUnquotedValue reports a bare value holding a character that a shell or
-	// another reader of the file may treat specially. Source only.
+	// RuleUnquotedValue reports a bare value holding a space or another
+	// character that a shell or another reader of the file may treat specially.
+	// Source only.
 	RuleUnquotedValue Rule = "unquoted-value"
+
+	// RuleIgnoreUnknown reports an [IgnoreMarker] naming a rule no check is
+	// known to report, most likely a misspelt one, which switches nothing off.
+	// The rules of a [Checker] or a schema are known once named with
+	// [WithKnownRules]. Source only.
+	RuleIgnoreUnknown Rule = "ignore-unknown"
 )
 
+// IgnoreMarker, in the comment above a row or the one trailing it, switches
+// rules off for that row alone, where [WithoutRules] would switch them off for
+// the whole file:
+//
+//	# envi:ignore empty-value
+//	SENTRY_DSN=
+//	legacy-name=x # envi:ignore key-not-canonical
+//
+// The rules follow the marker, separated by spaces or commas; the marker on its
+// own switches off every rule. It covers what is found about the row's key, by
+// any check, a [Checker] included. A finding about a line rather than a row,
+// such as a syntax error, cannot be switched off this way. A rule name no check
+// is known to report is itself reported, under [RuleIgnoreUnknown].
+const IgnoreMarker = "envi:ignore"
+
 // bit returns the rule's place in a configuration's disabled-rule mask, or 0
 // for a name this package does not know, which then disables nothing.
 func (r Rule) bit() uint32 {
@@ -80,11 +102,37 @@ func (r Rule) bit() uint32 {
 		return 1 << 4
 	case RuleUnquotedValue:
 		return 1 << 5
+	case RuleIgnoreUnknown:
+		return 1 << 6
 	default:
 		return 0
 	}
 }
 
+// A RuleInfo describes a rule, for the formats that carry rule metadata: see
+// [WriteSARIF].
+type RuleInfo struct {
+	Rule Rule
+
+	// Severity is the one the rule's findings usually have.
+	Severity Severity
+
+	// Summary says in a sentence what the rule reports.
+	Summary string
+}
+
+// builtinRules describes the rules of this package, in the order they are
+// declared.
+var builtinRules = []RuleInfo{
+	{RuleSyntax, SeverityError, "A line that could not be parsed."},
+	{RuleDuplicateKey, SeverityError, "A key given a live value twice, where the first value is silently discarded."},
+	{RuleKeyInvalid, SeverityError, "A key no shell would accept as the name of an environment variable."},
+	{RuleKeyNotCanonical, SeverityWarning, "A key written in a form that is rewritten on output, such as lower case or with hyphens."},
+	{RuleEmptyValue, SeverityWarning, "A live row whose value is empty."},
+	{RuleUnquotedValue, SeverityWarning, "A bare value holding a space or another character a shell or another reader may treat specially."},
+	{RuleIgnoreUnknown, SeverityWarning, "An envi:ignore comment naming a rule no check reports."},
+}
+
 // A Problem is one finding.
 type Problem struct {
 	// Rule names the check that produced the finding.
@@ -107,6 +155,10 @@ type Problem struct {
 	// Msg describes the finding in lower case, without position or severity:
 	// "value is empty".
 	Msg string `json:"message"`
+
+	// Fix is the edit that resolves the finding, the zero Fix when there is no
+	// obvious one. See [Report.Fixes].
+	Fix Fix `json:"fix,omitzero"`
 }
 
 // String renders the problem the way compilers and linters do, so that an
@@ -149,22 +201,182 @@ func (p Problem) String() string {
 type Report struct {
 	problems []Problem
 
-	// disabled is the mask from [WithoutRules], held here so that recording a
-	// finding needs nothing else.
-	disabled uint32
+	// disabled is the mask from [WithoutRules], and disabledOther the rules it
+	// named that have no bit, held here so that recording a finding needs
+	// nothing else.
+	disabled      uint32
+	disabledOther []Rule
+
+	// redaction is the policy from [WithRedaction], nil for none, and secrets
+	// the keys of the rows it found secret, whose values [Report.Redact]
+	// masks.
+	redaction *Redaction
+	secrets   map[string]bool
+
+	// ignores holds, for each key whose row says [IgnoreMarker], the rules it
+	// names; a nil slice stands for every rule.
+	ignores map[string][]Rule
+
+	// known lists the rules from [WithKnownRules], which an [IgnoreMarker] may
+	// name besides the built-in ones.
+	known []Rule
 }
 
-// newReport returns a report that will ignore the masked rules.
-func newReport(disabled uint32) *Report { return &Report{disabled: disabled} }
+// newReport returns a report configured by cfg: it ignores the rules switched
+// off, and hides the values of secret rows.
+func newReport(cfg config) *Report {
+	return &Report{
+		disabled:      cfg.disabledRules,
+		disabledOther: cfg.disabledOther,
+		redaction:     cfg.redaction,
+		known:         cfg.knownRules,
+	}
+}
+
+// Add records a finding of a [Checker]'s. It is dropped when its rule is
+// switched off with [WithoutRules]. A message that quotes a row's value should
+// take it from [Report.Redact], as the built-in rules do, so that a secret one
+// stays out of the report.
+func (r *Report) Add(p Problem) { r.record(p) }
+
+// Redact returns value, the value of the row under key, as a finding may quote
+// it: the mask of the [WithRedaction] policy when the row is secret, and the
+// value itself otherwise.
+//
+//	msg := "URL " + rep.Redact(row.Key(), row.Value()) + " is not https"
+func (r *Report) Redact(key, value string) string {
+	if r.secret(key) {
+		return r.redaction.hide(value)
+	}
+	return value
+}
 
 // record appends p unless its rule is switched off.
 func (r *Report) record(p Problem) {
-	if r.disabled&p.Rule.bit() != 0 {
+	if b := p.Rule.bit(); r.disabled&b != 0 || b == 0 && slices.Contains(r.disabledOther, p.Rule) || r.ignored(p) {
 		return
 	}
 	r.problems = append(r.problems, p)
 }
 
+// note tells the report about a row about to be checked, stated at line of the
+// source or 0: so that its value stays out of what the report says when the row
+// is secret, and so that the rules its comments switch off for it stay off.
+func (r *Report) note(row *Row, line int) {
+	if row.commented {
+		return
+	}
+	r.noteIgnores(row, line)
+	if r.redaction == nil || !r.redaction.Secret(row) {
+		return
+	}
+	if r.secrets == nil {
+		r.secrets = make(map[string]bool)
+	}
+	r.secrets[row.key] = true
+}
+
+// noteIgnores records the rules the row's comments switch off for its key, and
+// reports those of them no check is known to report. A key stated twice
+// gathers what both statements say.
+func (r *Report) noteIgnores(row *Row, line int) {
+	if !strings.Contains(row.comment, IgnoreMarker) && !strings.Contains(row.inline, IgnoreMarker) {
+		return
+	}
+	all := false
+	var rules []Rule
+	found := false
+	for _, c := range []string{ignorableComment(row), row.inline} {
+		for l := range strings.SplitSeq(c, "\n") {
+			named, ok := ignoredBy(l)
+			if !ok {
+				continue
+			}
+			found = true
+			all = all || len(named) == 0
+			rules = append(rules, named...)
+		}
+	}
+	if !found {
+		return
+	}
+	if r.ignores == nil {
+		r.ignores = make(map[string][]Rule)
+	}
+	if prev, seen := r.ignores[row.key]; all || (seen && prev == nil) {
+		r.ignores[row.key] = nil
+	} else {
+		r.ignores[row.key] = append(prev, rules...)
+	}
+
+	// Reported once the row's ignores are in, so that the marker can switch
+	// off its own warning.
+	for _, name := range rules {
+		if name.bit() == 0 && !slices.Contains(r.known, name) {
+			r.record(Problem{
+				Rule:     RuleIgnoreUnknown,
+				Severity: SeverityWarning,
+				Line:     line,
+				Key:      row.key,
+				Msg:      IgnoreMarker + " names unknown rule " + strconv.Quote(string(name)),
+			})
+		}
+	}
+}
+
+// ignorableComment returns the part of the row's comment an [IgnoreMarker] in
+// it applies to. For a parsed row that is the lines right above it: a blank
+// line parts a marker from the rows below.
+func ignorableComment(row *Row) string {
+	if !row.parsed {
+		return row.comment
+	}
+	i := len(row.rawPrefix)
+	for i > 0 && strings.TrimSpace(row.rawPrefix[i-1]) != "" {
+		i--
+	}
+	lines := make([]string, 0, len(row.rawPrefix)-i)
+	for _, l := range row.rawPrefix[i:] {
+		lines = append(lines, strings.TrimLeft(strings.TrimSpace(l), "#"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ignoredBy reads [IgnoreMarker] in one line of a comment: the rules named
+// after it, none meaning every rule, and whether the marker is there at all.
+// Rules are separated by spaces or commas.
+func ignoredBy(line string) ([]Rule, bool) {
+	words := strings.Fields(line)
+	i := slices.Index(words, IgnoreMarker)
+	if i < 0 {
+		return nil, false
+	}
+	var rules []Rule
+	for _, w := range words[i+1:] {
+		for name := range strings.SplitSeq(w, ",") {
+			if name != "" {
+				rules = append(rules, Rule(name))
+			}
+		}
+	}
+	return rules, true
+}
+
+// ignored reports whether the comments of the row p concerns switch its rule
+// off.
+func (r *Report) ignored(p Problem) bool {
+	if p.Key == "" {
+		return false
+	}
+	rules, ok := r.ignores[p.Key]
+	return ok && (rules == nil || slices.Contains(rules, p.Rule))
+}
+
+// secret reports whether the row under key was found secret.
+func (r *Report) secret(key string) bool {
+	return r.secrets[key]
+}
+
 // OK reports whether the document is valid: no finding of severity
 // [SeverityError]. Warnings do not make it false.
 func (r *Report) OK() bool { return r.Count(SeverityError) == 0 }
@@ -233,10 +445,12 @@ func (e *checkError) Error() string {
 // with unparsable lines kept verbatim so that writing it back does not delete
 // them. The error is reserved for a failure of the underlying reader.
 //
-// Rules can be switched off with [WithoutRules].
+// Rules can be switched off with [WithoutRules], [WithCheckers] adds rules of
+// the caller's own, and [WithRedaction] keeps secret values out of the
+// findings.
 func Check(r io.Reader, opts ...Option) (*Env, *Report, error) {
 	cfg := newConfig(opts)
-	rep := newReport(cfg.disabledRules)
+	rep := newReport(cfg)
 	s := newScanner(r, cfg)
 	s.check = true
 	d := &Decoder{s: s, cfg: cfg, report: rep}
@@ -245,6 +459,15 @@ func Check(r io.Reader, opts ...Option) (*Env, *Report, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	if len(cfg.checkers) > 0 {
+		// The checkers need the whole document, so they run once it is
+		// built; sorting by line then puts their findings back in file order,
+		// after the built-in ones on the same line.
+		for r := range env.Rows() {
+			runCheckers(cfg.checkers, rep, r, r.origin.Line, env)
+		}
+		slices.SortStableFunc(rep.problems, func(a, b Problem) int { return a.Line - b.Line })
+	}
 	return env, rep, nil
 }
 
@@ -274,12 +497,15 @@ func CheckFile(path string, opts ...Option) (*Env, *Report, error) {
 // The rest — [RuleSyntax], [RuleDuplicateKey], [RuleKeyNotCanonical] and
 // [RuleUnquotedValue] — describe how a file is written rather than what it
 // holds, and a document that has been parsed no longer remembers that. Use
-// [Check] on the source to run them.
+// [Check] on the source to run them. The checkers from [WithCheckers] run too,
+// each row with line 0.
 func (e *Env) Check(opts ...Option) *Report {
 	cfg := newConfig(opts)
-	rep := newReport(cfg.disabledRules)
+	rep := newReport(cfg)
 	for r := range e.Rows() {
+		rep.note(r, 0)
 		checkRow(rep, r.key, r.value, r.commented, 0)
+		runCheckers(cfg.checkers, rep, r, 0, e)
 	}
 	return rep
 }
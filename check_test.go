@@ -386,6 +386,130 @@ func TestEnvCheckHonoursWithoutRules(t *testing.T) {
 	}
 }
 
+// httpsOnly is a custom rule: every *_URL must be https.
+var httpsOnly = envi.CheckerFunc(func(r *envi.Row, line int, _ *envi.Env, rep *envi.Report) {
+	if strings.HasSuffix(r.Key(), "_URL") && !strings.HasPrefix(r.Value(), "https://") {
+		rep.Add(envi.Problem{Rule: "url-https", Line: line, Key: r.Key(), Msg: "URL " + rep.Redact(r.Key(), r.Value()) + " is not https"})
+	}
+})
+
+func TestWithCheckers(t *testing.T) {
+	t.Parallel()
+
+	const src = "API_URL=http://api\nEMPTY=\nOK_URL=https://ok\n# OLD_URL=http://old\n"
+
+	_, rep, err := envi.CheckString(src, envi.WithCheckers(httpsOnly))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The custom finding sits in file order among the built-in ones, and the
+	// commented-out row is not passed.
+	if got, want := rep.String(), "1: error: url-https: URL http://api is not https (API_URL)\n"+
+		"2: warning: empty-value: value is empty (EMPTY)\n"; got != want {
+		t.Errorf("got\n%swant\n%s", got, want)
+	}
+
+	_, rep, _ = envi.CheckString(src, envi.WithCheckers(httpsOnly), envi.WithoutRules("url-https"))
+	if got := rulesOf(rep); !slices.Equal(got, []envi.Rule{envi.RuleEmptyValue}) {
+		t.Errorf("with url-https off: rules = %v", got)
+	}
+
+	e := mustParse(t, src)
+	rep = e.Check(envi.WithCheckers(nil, httpsOnly))
+	if got := rulesOf(rep); !slices.Equal(got, []envi.Rule{"url-https", envi.RuleEmptyValue}) {
+		t.Errorf("Env.Check: rules = %v", got)
+	}
+	for p := range rep.All() {
+		if p.Line != 0 {
+			t.Errorf("Env.Check: Line = %d, want 0", p.Line)
+		}
+	}
+
+	// A secret value stays out of a custom finding's message too.
+	_, rep, _ = envi.CheckString("DB_URL=http://x # @secret\n", envi.WithCheckers(httpsOnly), envi.WithRedaction(nil))
+	if rep.Len() != 1 || strings.Contains(rep.String(), "http://x") {
+		t.Errorf("want one finding, without the secret:\n%s", rep)
+	}
+}
+
+// A checker sees the whole document, for rules across rows.
+func TestCheckerSeesTheDocument(t *testing.T) {
+	t.Parallel()
+
+	needsPort := envi.CheckerFunc(func(r *envi.Row, line int, doc *envi.Env, rep *envi.Report) {
+		if r.Key() == "HOST" && !doc.Has("PORT") {
+			rep.Add(envi.Problem{Rule: "host-port", Severity: envi.SeverityWarning, Line: line, Key: r.Key(), Msg: "HOST without PORT"})
+		}
+	})
+	_, rep, _ := envi.CheckString("A=1\nHOST=h\n", envi.WithCheckers(needsPort))
+	if got := linesOf(rep); !slices.Equal(got, []int{2}) {
+		t.Errorf("lines = %v, want [2]", got)
+	}
+	if _, rep, _ = envi.CheckString("HOST=h\nPORT=1\n", envi.WithCheckers(needsPort)); rep.Len() != 0 {
+		t.Errorf("a later PORT was not seen:\n%s", rep)
+	}
+}
+
+func TestIgnoreMarker(t *testing.T) {
+	t.Parallel()
+
+	const src = `# envi:ignore empty-value
+SENTRY_DSN=
+OTHER=
+legacy-name=$x # envi:ignore key-not-canonical, unquoted-value
+odd-name= # envi:ignore
+API_URL=http://a # envi:ignore url-https
+
+# A blank line detaches the marker below, so it covers nothing.
+# envi:ignore empty-value
+
+LAST=
+`
+	_, rep, err := envi.CheckString(src, envi.WithCheckers(httpsOnly), envi.WithKnownRules("url-https"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := linesOf(rep), []int{3, 11}; !slices.Equal(got, want) {
+		t.Errorf("lines = %v, want %v:\n%s", got, want, rep)
+	}
+
+	// A document in memory honours the marker as well.
+	e := envi.New(envi.NewRow("EMPTY", "").SetInlineComment("envi:ignore empty-value"), envi.NewRow("K", ""))
+	if got := e.Check().Len(); got != 1 {
+		t.Errorf("Env.Check: Len = %d, want 1", got)
+	}
+}
+
+func TestIgnoreMarkerUnknownRule(t *testing.T) {
+	t.Parallel()
+
+	const src = `# envi:ignore empty-valu
+A=
+B= # envi:ignore empty-value, host-port
+C= # envi:ignore ignore-unknown typo
+`
+	_, rep, err := envi.CheckString(src, envi.WithKnownRules("host-port"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rulesOf(rep), []envi.Rule{envi.RuleIgnoreUnknown, envi.RuleEmptyValue, envi.RuleEmptyValue}; !slices.Equal(got, want) {
+		t.Errorf("rules = %v, want %v:\n%s", got, want, rep)
+	}
+	if got, want := linesOf(rep), []int{2, 2, 4}; !slices.Equal(got, want) {
+		t.Errorf("lines = %v, want %v", got, want)
+	}
+	for p := range rep.All() {
+		if p.Rule == envi.RuleIgnoreUnknown && p.Msg != `envi:ignore names unknown rule "empty-valu"` {
+			t.Errorf("Msg = %q", p.Msg)
+		}
+	}
+
+	// It switches off like any other rule.
+	if _, rep, _ = envi.CheckString(src, envi.WithoutRules(envi.RuleIgnoreUnknown)); rep.Len() != 2 {
+		t.Errorf("WithoutRules: Len = %d, want 2:\n%s", rep.Len(), rep)
+	}
+}
+
 func TestZeroReportIsUsable(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,41 @@
+package envi
+
+// A Checker is a check of the caller's own, run with [WithCheckers] beside the
+// built-in rules. It sees each live row in turn, together with the document
+// the row belongs to, and records what it finds in the report under a [Rule]
+// name of its choosing:
+//
+//	httpsOnly := envi.CheckerFunc(func(r *envi.Row, line int, _ *envi.Env, rep *envi.Report) {
+//		if strings.HasSuffix(r.Key(), "_URL") && !strings.HasPrefix(r.Value(), "https://") {
+//			rep.Add(envi.Problem{Rule: "url-https", Line: line, Key: r.Key(), Msg: "URL is not https"})
+//		}
+//	})
+//
+// Such a rule is switched off with [WithoutRules] like a built-in one, and
+// turns up in [Report.Text] and [Report.JSON] the same way. Pick names that do
+// not collide with this package's: a name it knows is treated as its own.
+type Checker interface {
+	// CheckRow checks one row, stated at line of the source, or 0 when the
+	// document is checked in memory with [Env.Check]. Commented-out rows are
+	// not passed, as they configure nothing; doc is the whole document, for a
+	// rule that looks across rows, and is not to be changed.
+	CheckRow(row *Row, line int, doc *Env, report *Report)
+}
+
+// CheckerFunc adapts a function to a [Checker].
+type CheckerFunc func(row *Row, line int, doc *Env, report *Report)
+
+// CheckRow calls f.
+func (f CheckerFunc) CheckRow(row *Row, line int, doc *Env, report *Report) {
+	f(row, line, doc, report)
+}
+
+// runCheckers runs cs over one row, unless it is commented out.
+func runCheckers(cs []Checker, rep *Report, row *Row, line int, doc *Env) {
+	if row.commented {
+		return
+	}
+	for _, c := range cs {
+		c.CheckRow(row, line, doc, rep)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// A baselineEntry is one kind of known finding in a -baseline file. It is
+// matched by file, rule and key but not by line, so that editing the lines
+// around a known finding does not bring it back. Count says how many findings
+// of the kind are known, when more than one: a finding about a line rather
+// than a key, such as a syntax error, has no key to tell it from the next.
+//
+//	{
+//	  "findings": [
+//	    {"file": "config/.env", "rule": "empty-value", "key": "SENTRY_DSN"},
+//	    {"file": "config/.env", "rule": "syntax", "count": 2}
+//	  ]
+//	}
+type baselineEntry struct {
+	File string `json:"file"`
+	Rule string `json:"rule"`
+	Key  string `json:"key,omitempty"`
+}
+
+// baselineCount is an entry as a -baseline file writes it, with its count;
+// one finding of the kind leaves the count out.
+type baselineCount struct {
+	baselineEntry
+	Count int `json:"count,omitempty"`
+}
+
+// baselineFile is the form of a -baseline file.
+type baselineFile struct {
+	Findings []baselineCount `json:"findings"`
+}
+
+// A baseline is the set of findings a project has accepted for now, kept in
+// the file at path, with how many of each kind are still to be matched.
+type baseline struct {
+	path  string
+	dir   string
+	known map[baselineEntry]int
+}
+
+// newBaseline returns an empty baseline to be kept in the file at path.
+func newBaseline(path string) *baseline {
+	dir := filepath.Dir(path)
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return &baseline{path: path, dir: dir, known: make(map[baselineEntry]int)}
+}
+
+// loadBaseline reads a -baseline file.
+func loadBaseline(path string) (*baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc baselineFile
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	b := newBaseline(path)
+	for _, f := range doc.Findings {
+		e := f.baselineEntry
+		e.File = filepath.ToSlash(filepath.Clean(filepath.FromSlash(e.File)))
+		b.known[e] += max(f.Count, 1)
+	}
+	return b, nil
+}
+
+// entryOf returns the entry that covers p, a finding in the file at path.
+//
+// The file is named relative to the directory of the baseline, with forward
+// slashes, so that it is the same entry however the file was named on the
+// command line — relative, absolute, through another working directory — and
+// on whichever system the baseline was written. Standard input is "-".
+func (b *baseline) entryOf(path string, p envi.Problem) baselineEntry {
+	file := path
+	if path != stdinPath {
+		if abs, err := filepath.Abs(path); err == nil {
+			if rel, err := filepath.Rel(b.dir, abs); err == nil {
+				file = rel
+			}
+		}
+		file = filepath.ToSlash(filepath.Clean(file))
+	}
+	return baselineEntry{File: file, Rule: string(p.Rule), Key: p.Key}
+}
+
+// take reports whether the baseline lists p, a finding in the file at path,
+// and uses up the listing: an entry counting n findings covers the first n of
+// its kind, and a finding beyond those is new. A nil baseline lists nothing.
+func (b *baseline) take(path string, p envi.Problem) bool {
+	if b == nil {
+		return false
+	}
+	e := b.entryOf(path, p)
+	if b.known[e] == 0 {
+		return false
+	}
+	b.known[e]--
+	return true
+}
+
+// add lists p, a finding in the file at path.
+func (b *baseline) add(path string, p envi.Problem) {
+	b.known[b.entryOf(path, p)]++
+}
+
+// write writes the baseline to its file, the entries sorted and each once, so
+// that a baseline regenerated from the same findings is the same file.
+func (b *baseline) write() error {
+	doc := baselineFile{Findings: make([]baselineCount, 0, len(b.known))}
+	for e, n := range b.known {
+		if n == 1 {
+			n = 0
+		}
+		doc.Findings = append(doc.Findings, baselineCount{e, n})
+	}
+	slices.SortFunc(doc.Findings, func(x, y baselineCount) int {
+		return cmp.Or(cmp.Compare(x.File, y.File), cmp.Compare(x.Rule, y.Rule), cmp.Compare(x.Key, y.Key))
+	})
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, doc); err != nil {
+		return err
+	}
+	return os.WriteFile(b.path, buf.Bytes(), 0o644)
+}
@@ -1,57 +1,178 @@
 package main
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	envi "github.com/efureev/envi/v2"
+	"github.com/efureev/envi/v2/schema"
 )
 
 // cmdCheck reports everything wrong with the given files.
 //
 // Unlike parsing, checking does not stop at the first malformed line, so one
 // run tells the whole story. Findings carry the file they came from, in the
-// form an editor or a CI log turns into a link.
+// form an editor or a CI log turns into a link. Policies of the project's own
+// come from a -rules file, see patternRule, and the keys a file must set and
+// the form of their values from a -schema file. With -fix the findings that
+// carry a fix are resolved in place, and only the rest count against the file;
+// -n says what would be fixed, and exits as the run without it would.
+// Findings a -baseline file lists are known and left out altogether, so that a
+// project can adopt the checks before it has cleaned up every legacy file,
+// though no more of a kind than it counts; -write-baseline records the findings
+// of today as that file.
 func cmdCheck(args []string, s ioStreams) int {
 	fs := newFlags("check", s)
-	asJSON := fs.Bool("json", false, "write the findings as JSON")
+	format := fs.String("format", "text", "write the findings as text, json, sarif, checkstyle or github")
+	asJSON := fs.Bool("json", false, "the same as -format json")
 	strict := fs.Bool("strict", false, "fail on warnings as well as errors")
 	off := fs.String("off", "", "comma-separated rules to switch off")
+	rulesFile := fs.String("rules", "", "JSON `file` of pattern rules to check as well")
+	schemaFile := fs.String("schema", "", "check against the schema in `file`, such as .env.schema")
+	fix := fs.Bool("fix", false, "apply the fixes the findings carry, and report the rest")
+	dry := fs.Bool("n", false, "with -fix, report what would be fixed without writing the files")
+	baselineFile := fs.String("baseline", "", "leave out the known findings listed in `file`")
+	writeBase := fs.Bool("write-baseline", false, "write the findings to the -baseline file instead of reporting them")
+	mask := maskFlag(fs)
 	if err := fs.Parse(args); err != nil {
 		return exitFailure
 	}
 
+	if *asJSON {
+		*format = "json"
+	}
+	switch *format {
+	case "text", "json", "sarif", "checkstyle", "github":
+	default:
+		return fail(s.err, fmt.Errorf("unknown format %q: want text, json, sarif, checkstyle or github", *format))
+	}
+
 	paths := fs.Args()
 	if len(paths) == 0 {
 		paths = []string{defaultFile}
 	}
 
+	var sch *schema.Schema
+	if *schemaFile != "" {
+		var err error
+		if sch, err = schema.Load(*schemaFile); err != nil {
+			return fail(s.err, err)
+		}
+	}
+
 	var opts []envi.Option
 	if rules := parseRules(*off); len(rules) > 0 {
 		opts = append(opts, envi.WithoutRules(rules...))
 	}
+	if *mask {
+		// The schema knows secrets the default policy cannot guess.
+		var p *envi.Redaction
+		if sch != nil {
+			p = sch.Redaction()
+		}
+		opts = append(opts, envi.WithRedaction(p))
+	}
+
+	// known lists the findings to leave out, and accepted those to write
+	// out with -write-baseline.
+	var known, accepted *baseline
+	switch {
+	case *writeBase && *baselineFile == "":
+		return fail(s.err, errors.New("-write-baseline needs -baseline file"))
+	case *writeBase && *fix:
+		// Recording the findings of today is no time to change the files.
+		return fail(s.err, errors.New("-write-baseline cannot be combined with -fix"))
+	case *writeBase:
+		accepted = newBaseline(*baselineFile)
+	case *baselineFile != "":
+		var err error
+		if known, err = loadBaseline(*baselineFile); err != nil {
+			return fail(s.err, err)
+		}
+	}
+
+	var rules []patternRule
+	if *rulesFile != "" {
+		var err error
+		if rules, err = loadRules(*rulesFile); err != nil {
+			return fail(s.err, err)
+		}
+	}
+	// The rules of -rules and -schema are described in SARIF, and an
+	// envi:ignore comment may name them.
+	var described []envi.RuleInfo
+	for _, r := range rules {
+		described = append(described, r.info())
+	}
+	if sch != nil {
+		described = append(described, schema.Rules()...)
+	}
+	if len(described) > 0 {
+		named := make([]envi.Rule, len(described))
+		for i, d := range described {
+			named[i] = d.Rule
+		}
+		opts = append(opts, envi.WithKnownRules(named...))
+	}
 
 	found := false
 	var collected []jsonFinding
+	var reports []envi.FileReport
 
 	for _, path := range paths {
+		if *fix && !*dry && path == stdinPath {
+			return fail(s.err, errors.New("-fix cannot rewrite standard input; add -n"))
+		}
 		in, err := openReader(path, s)
 		if err != nil {
 			return fail(s.err, err)
 		}
-		_, report, err := envi.Check(in, opts...)
+		fileOpts := append(slices.Clip(opts), envi.WithCheckers(checkersFor(rules, path)...))
+		env, report, err := envi.Check(in, fileOpts...)
 		closeReader(in)
 		if err != nil {
 			return fail(s.err, fmt.Errorf("%s: %w", path, err))
 		}
+		if sch != nil {
+			sch.Check(env, report)
+		}
 
-		if !report.OK() || (*strict && report.Len() > 0) {
-			found = true
+		var fixed []envi.Fix
+		if *fix {
+			fixed = env.ApplyFixes(report.Fixes()...)
+			if len(fixed) > 0 && !*dry {
+				if err := writeInPlace(path, env); err != nil {
+					return fail(s.err, err)
+				}
+			}
 		}
 
-		for p := range report.All() {
-			if *asJSON {
-				collected = append(collected, jsonFinding{
+		// What -fix resolved is reported in text and JSON, which say so; the
+		// other formats are read by machines that want what is left.
+		left := &envi.Report{}
+		for _, p := range slices.SortedStableFunc(report.All(), byLine) {
+			done := !p.Fix.IsZero() && slices.Contains(fixed, p.Fix)
+			if known.take(path, p) {
+				continue
+			}
+			if accepted != nil {
+				if !done {
+					accepted.add(path, p)
+				}
+				continue
+			}
+			if !done {
+				left.Add(p)
+				if p.Severity == envi.SeverityError || *strict {
+					found = true
+				}
+			}
+			switch *format {
+			case "json":
+				f := jsonFinding{
 					File:     path,
 					Rule:     string(p.Rule),
 					Severity: p.Severity.String(),
@@ -59,17 +180,48 @@ func cmdCheck(args []string, s ioStreams) int {
 					Col:      p.Col,
 					Key:      p.Key,
 					Message:  p.Msg,
-				})
-				continue
+					Fixed:    done && !*dry,
+				}
+				if !p.Fix.IsZero() {
+					f.Fix = p.Fix.Msg
+				}
+				collected = append(collected, f)
+			case "github":
+				if !done {
+					s.out.println(githubCommand(path, p))
+				}
+			case "text":
+				switch {
+				case done && *dry:
+					s.out.printf("%s:%d: would fix: %s: %s\n", path, p.Fix.Line, p.Rule, p.Fix.Msg)
+				case done:
+					s.out.printf("%s:%d: fixed: %s: %s\n", path, p.Fix.Line, p.Rule, p.Fix.Msg)
+				default:
+					s.out.printf("%s:%s\n", path, p)
+				}
 			}
-			s.out.printf("%s:%s\n", path, p)
 		}
+		reports = append(reports, envi.FileReport{File: path, Report: left, Rules: described})
 	}
 
-	if *asJSON {
-		if err := writeJSON(s.out, nonNil(collected)); err != nil {
+	if accepted != nil {
+		if err := accepted.write(); err != nil {
 			return fail(s.err, err)
 		}
+		return exitOK
+	}
+
+	var err error
+	switch *format {
+	case "json":
+		err = writeJSON(s.out, nonNil(collected))
+	case "sarif":
+		err = envi.WriteSARIF(s.out, reports...)
+	case "checkstyle":
+		err = envi.WriteCheckstyle(s.out, reports...)
+	}
+	if err != nil {
+		return fail(s.err, err)
 	}
 
 	if found {
@@ -89,6 +241,47 @@ type jsonFinding struct {
 	Col      int    `json:"col,omitempty"`
 	Key      string `json:"key,omitempty"`
 	Message  string `json:"message"`
+
+	// Fix describes the edit that resolves the finding, and Fixed says
+	// whether -fix made it.
+	Fix   string `json:"fix,omitempty"`
+	Fixed bool   `json:"fixed,omitempty"`
+}
+
+// githubCommand renders a finding as a GitHub Actions workflow command, which
+// the runner turns into an annotation on the line it names:
+//
+//	::error file=.env,line=4,col=12,title=syntax::unterminated quoted value
+func githubCommand(path string, p envi.Problem) string {
+	level := "error"
+	if p.Severity == envi.SeverityWarning {
+		level = "warning"
+	}
+	var b strings.Builder
+	b.WriteString("::" + level + " file=" + githubEscape(path, true))
+	if p.Line > 0 {
+		fmt.Fprintf(&b, ",line=%d", p.Line)
+	}
+	if p.Col > 0 {
+		fmt.Fprintf(&b, ",col=%d", p.Col)
+	}
+	b.WriteString(",title=" + githubEscape(string(p.Rule), true) + "::")
+	msg := p.Msg
+	if p.Key != "" {
+		msg += " (" + p.Key + ")"
+	}
+	b.WriteString(githubEscape(msg, false))
+	return b.String()
+}
+
+// githubEscape escapes s for a workflow command: the message after "::", or
+// with property set, the value of one of the properties before it.
+func githubEscape(s string, property bool) string {
+	r := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	if property {
+		r = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+	}
+	return r.Replace(s)
 }
 
 // parseRules splits the -off value. Unknown names are passed through: the
@@ -114,3 +307,14 @@ func nonNil(f []jsonFinding) []jsonFinding {
 	}
 	return f
 }
+
+// byLine orders findings by the line they are on, so that those of a -schema,
+// which come after the parser's, print in the order of the file. A finding
+// about no line, such as a required key the file lacks, goes last; findings on
+// one line keep the order they were made in.
+func byLine(a, b envi.Problem) int {
+	if (a.Line == 0) != (b.Line == 0) {
+		return cmp.Compare(b.Line, a.Line)
+	}
+	return cmp.Compare(a.Line, b.Line)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/rand"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// gitlabMaxSize is the largest dotenv report GitLab accepts by default.
+const gitlabMaxSize = 5 << 10
+
+// writeGitHub writes pairs in the form GitHub Actions reads from $GITHUB_ENV:
+//
+//	envi export -format github .env >> "$GITHUB_ENV"
+//
+// A single-line value is written as KEY=value, which the runner takes
+// literally: no quotes, no escapes. A value spanning lines needs the heredoc
+// form, KEY<<DELIMITER, ended by a line holding the delimiter alone. The
+// delimiter is random, the way GitHub's own toolkit does it, because a fixed
+// one is a line a value could contain — and a value that can end the heredoc
+// early can set any variable it likes after it.
+func writeGitHub(s ioStreams, pairs [][2]string) {
+	for _, kv := range pairs {
+		key, value := kv[0], kv[1]
+		if reserved := githubReserved(key); reserved != "" {
+			warnf(s.err, "envi: skipping %s: GitHub does not let a workflow set %s variables\n", key, reserved)
+			continue
+		}
+		if !strings.ContainsAny(value, "\r\n") {
+			s.out.printf("%s=%s\n", key, value)
+			continue
+		}
+		d := heredocDelimiter(value)
+		s.out.printf("%s<<%s\n%s\n%s\n", key, d, value, d)
+	}
+}
+
+// githubReserved returns the prefix of a key that names a variable the runner
+// refuses to have set, or "".
+func githubReserved(key string) string {
+	for _, p := range []string{"GITHUB_", "RUNNER_"} {
+		if strings.HasPrefix(key, p) {
+			return p + "*"
+		}
+	}
+	return ""
+}
+
+// heredocDelimiter returns a random delimiter that no line of value equals.
+func heredocDelimiter(value string) string {
+	lines := strings.FieldsFunc(value, func(r rune) bool { return r == '\n' || r == '\r' })
+	for {
+		d := "ghadelimiter_" + rand.Text()
+		if !slices.Contains(lines, d) {
+			return d
+		}
+	}
+}
+
+// writeGitLab writes pairs as a GitLab dotenv report, for artifacts:reports:dotenv.
+//
+// GitLab reads a narrower format than a .env file: names of letters, digits and
+// underscores only, one line per value, no quoting — a quote is kept as part of
+// the value — and whitespace around a value trimmed away. A pair the report
+// cannot carry is skipped with a warning naming the key and the rule, and the
+// rest is written: a job that loses one variable beats one that loses them all.
+// GitLab also refuses a report over 5 KB, which is warned about as a whole.
+func writeGitLab(s ioStreams, pairs [][2]string) {
+	size := 0
+	for _, kv := range pairs {
+		key, value := kv[0], kv[1]
+		if msg := gitlabProblem(key, value); msg != "" {
+			warnf(s.err, "envi: skipping %s: %s\n", key, msg)
+			continue
+		}
+		line := key + "=" + value + "\n"
+		size += len(line)
+		s.out.print(line)
+	}
+	if size > gitlabMaxSize {
+		warnf(s.err, "envi: the report is %d bytes; GitLab accepts at most %d\n", size, gitlabMaxSize)
+	}
+}
+
+// gitlabProblem returns the GitLab dotenv rule a pair breaks, or "".
+func gitlabProblem(key, value string) string {
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_') {
+			return "GitLab names hold only letters, digits and underscores"
+		}
+	}
+	switch {
+	case strings.ContainsAny(value, "\r\n"):
+		return "GitLab does not support multi-line values"
+	case !utf8.ValidString(value):
+		return "GitLab reads only UTF-8"
+	case strings.TrimSpace(value) != value:
+		return "GitLab would trim the whitespace around the value"
+	}
+	return ""
+}
@@ -10,6 +10,7 @@ import (
 	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	envi "github.com/efureev/envi/v2"
 )
@@ -96,6 +97,308 @@ func TestCheck(t *testing.T) {
 		}
 	})
 
+	t.Run("-rules adds pattern rules", func(t *testing.T) {
+		t.Parallel()
+
+		rules := writeFile(t, "rules.json", `{"rules": [
+  {"name": "url-https", "keys": "*_url", "match": "https://*", "message": "URL is not https"},
+  {"name": "no-localhost", "files": "*prod*", "reject": "*localhost*", "severity": "warning"}
+]}`)
+		dir := t.TempDir()
+		prod := filepath.Join(dir, ".env.prod")
+		dev := filepath.Join(dir, ".env.dev")
+		for _, p := range []string{prod, dev} {
+			if err := os.WriteFile(p, []byte("API_URL=http://localhost/api\nCDN_URL=https://cdn/x\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got := execCLI("", "check", "-rules", rules, prod, dev)
+		want := prod + ":1: error: url-https: URL is not https (API_URL)\n" +
+			prod + ":1: warning: no-localhost: value matches *localhost* (API_URL)\n" +
+			dev + ":1: error: url-https: URL is not https (API_URL)\n"
+		if got.code != exitFound || got.stdout != want {
+			t.Errorf("code %d, stdout\n%swant\n%s", got.code, got.stdout, want)
+		}
+
+		got = execCLI("", "check", "-rules", rules, "-off", "url-https", dev)
+		if got.code != exitOK || got.stdout != "" {
+			t.Errorf("with url-https off: code %d, stdout %q", got.code, got.stdout)
+		}
+	})
+
+	t.Run("envi:ignore may name the rules of -rules and -schema", func(t *testing.T) {
+		t.Parallel()
+
+		rules := writeFile(t, "rules.json", `{"rules": [{"name": "url-https", "keys": "*_url", "match": "https://*"}]}`)
+		sch := writeFile(t, ".env.schema", "API_URL= # url\n")
+		path := writeFile(t, ".env", "API_URL=http://a # envi:ignore url-https, schema-unknown, url-http\n")
+		got := execCLI("", "check", "-rules", rules, "-schema", sch, path)
+		want := path + ":1: warning: ignore-unknown: envi:ignore names unknown rule \"url-http\" (API_URL)\n"
+		if got.code != exitOK || got.stdout != want {
+			t.Errorf("code %d, stdout\n%swant\n%s", got.code, got.stdout, want)
+		}
+
+		got = execCLI("", "check", path)
+		if !strings.Contains(got.stdout, `unknown rule "url-https"`) || !strings.Contains(got.stdout, `unknown rule "schema-unknown"`) {
+			t.Errorf("without -rules and -schema, stdout\n%s", got.stdout)
+		}
+	})
+
+	t.Run("-rules rejects a rule that cannot fire", func(t *testing.T) {
+		t.Parallel()
+
+		rules := writeFile(t, "rules.json", `{"rules": [{"name": "nothing", "keys": "*"}]}`)
+		got := execCLI("", "check", "-rules", rules, writeFile(t, ".env", "A=1\n"))
+		if got.code != exitFailure || !strings.Contains(got.stderr, "rule 1: nothing: neither match nor reject") {
+			t.Errorf("code %d, stderr %q", got.code, got.stderr)
+		}
+	})
+
+	t.Run("-schema findings print in line order", func(t *testing.T) {
+		t.Parallel()
+
+		sch := writeFile(t, ".env.schema", "A= # int\nLEGACY=\nR= # required\n")
+		path := writeFile(t, ".env", "A=x\nlegacy=1\n")
+		got := execCLI("", "check", "-schema", sch, path)
+		want := path + ":1: error: schema-type: value \"x\" is not an integer (A)\n" +
+			path + ":2: warning: key-not-canonical: key is written as \"legacy\" (LEGACY)\n" +
+			path + ":R: error: schema-required: required key is not set\n"
+		if got.code != exitFound || got.stdout != want {
+			t.Errorf("code %d, stdout\n%swant\n%s", got.code, got.stdout, want)
+		}
+	})
+
+	t.Run("-schema rejects a deploy config", func(t *testing.T) {
+		t.Parallel()
+
+		sch := writeFile(t, ".env.schema", "DATABASE_URL= # url required secret\nPORT=8080 # int\n")
+		path := writeFile(t, ".env", "PORT=abc\n")
+		got := execCLI("", "check", "-schema", sch, path)
+		want := path + ":1: error: schema-type: value \"abc\" is not an integer (PORT)\n" +
+			path + ":DATABASE_URL: error: schema-required: required key is not set\n"
+		if got.code != exitFound || got.stdout != want {
+			t.Errorf("code %d, stdout\n%swant\n%s", got.code, got.stdout, want)
+		}
+
+		path = writeFile(t, ".env", "DATABASE_URL=postgres://db/app\nPORT=80\n")
+		if got = execCLI("", "check", "-schema", sch, path); got.code != exitOK || got.stdout != "" {
+			t.Errorf("valid file: code %d, stdout %q", got.code, got.stdout)
+		}
+
+		bad := writeFile(t, ".env.schema", "PORT= # integer\n")
+		if got = execCLI("", "check", "-schema", bad, path); got.code != exitFailure || !strings.Contains(got.stderr, `unknown annotation "integer"`) {
+			t.Errorf("bad schema: code %d, stderr %q", got.code, got.stderr)
+		}
+	})
+
+	t.Run("-fix resolves what it can", func(t *testing.T) {
+		t.Parallel()
+
+		const src = "# top\napp-name=x\nKEEP =  'spaced'\nDUP=1\nDUP=2\nEMPTY=\n"
+		path := writeFile(t, ".env", src)
+
+		got := execCLI("", "check", "-fix", "-n", path)
+		want := path + ":2: would fix: key-not-canonical: write the key as APP_NAME\n" +
+			path + ":4: would fix: duplicate-key: remove the definition on line 4\n" +
+			path + ":6: warning: empty-value: value is empty (EMPTY)\n"
+		if got.code != exitOK || got.stdout != want {
+			t.Errorf("-n: code %d, stdout\n%swant\n%s", got.code, got.stdout, want)
+		}
+		if readFile(t, path) != src {
+			t.Error("-n wrote the file")
+		}
+		got = execCLI("", "check", "-fix", "-n", "-json", path)
+		if strings.Contains(got.stdout, `"fixed"`) || !strings.Contains(got.stdout, `"fix": "write the key as APP_NAME"`) {
+			t.Errorf("-n -json says something was fixed:\n%s", got.stdout)
+		}
+
+		want = strings.ReplaceAll(want, "would fix", "fixed")
+		if got = execCLI("", "check", "-fix", path); got.stdout != want {
+			t.Errorf("stdout\n%swant\n%s", got.stdout, want)
+		}
+		if got, want := readFile(t, path), "# top\nAPP_NAME=x\nKEEP =  'spaced'\nDUP=2\nEMPTY=\n"; got != want {
+			t.Errorf("file = %q, want %q", got, want)
+		}
+
+		if got = execCLI("", "check", "-fix", "-"); got.code != exitFailure {
+			t.Errorf("stdin without -n: code %d", got.code)
+		}
+	})
+
+	t.Run("-format github writes workflow commands", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeFile(t, ".env", "K=\"a\nEMPTY=\n")
+		got := execCLI("", "check", "-format", "github", path)
+		file := strings.NewReplacer(":", "%3A", ",", "%2C").Replace(path)
+		want := "::error file=" + file + ",line=1,col=3,title=syntax::unterminated quoted value\n"
+		if got.code != exitFound || !strings.HasPrefix(got.stdout, want) {
+			t.Errorf("code %d, stdout\n%swant prefix\n%s", got.code, got.stdout, want)
+		}
+	})
+
+	t.Run("-format sarif aggregates files into one log", func(t *testing.T) {
+		t.Parallel()
+
+		a := writeFile(t, "a.env", "app-name=x\n")
+		b := writeFile(t, "b.env", "K=\"a\n")
+		got := execCLI("", "check", "-format", "sarif", a, b)
+
+		var log struct {
+			Runs []struct {
+				Results []struct {
+					RuleID string `json:"ruleId"`
+				} `json:"results"`
+			} `json:"runs"`
+		}
+		if err := json.Unmarshal([]byte(got.stdout), &log); err != nil {
+			t.Fatalf("output does not parse: %v\n%s", err, got.stdout)
+		}
+		if len(log.Runs) != 1 || len(log.Runs[0].Results) != 2 {
+			t.Errorf("want one run of two results:\n%s", got.stdout)
+		}
+		if got.code != exitFound {
+			t.Errorf("code = %d, want %d", got.code, exitFound)
+		}
+	})
+
+	t.Run("-format sarif describes the rules of -rules and -schema", func(t *testing.T) {
+		t.Parallel()
+
+		rules := writeFile(t, "rules.json", `{"rules": [{"name": "url-https", "keys": "*_URL", "match": "https://*"}]}`)
+		sch := writeFile(t, ".env.schema", "API_URL= # url\n")
+		got := execCLI("", "check", "-format", "sarif", "-rules", rules, "-schema", sch, writeFile(t, ".env", "API_URL=http://x\n"))
+
+		var log struct {
+			Runs []struct {
+				Tool struct {
+					Driver struct {
+						Rules []struct {
+							ID               string `json:"id"`
+							ShortDescription struct {
+								Text string `json:"text"`
+							} `json:"shortDescription"`
+						} `json:"rules"`
+					} `json:"driver"`
+				} `json:"tool"`
+			} `json:"runs"`
+		}
+		if err := json.Unmarshal([]byte(got.stdout), &log); err != nil {
+			t.Fatalf("output does not parse: %v\n%s", err, got.stdout)
+		}
+		described := map[string]string{}
+		for _, r := range log.Runs[0].Tool.Driver.Rules {
+			described[r.ID] = r.ShortDescription.Text
+		}
+		if got := described["url-https"]; got != "The value of keys *_URL does not match https://*." {
+			t.Errorf("url-https is described as %q", got)
+		}
+		if described["schema-type"] == "" {
+			t.Errorf("schema-type has no description: %v", described)
+		}
+	})
+
+	t.Run("-format checkstyle", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeFile(t, ".env", "EMPTY=\n")
+		got := execCLI("", "check", "-format", "checkstyle", path)
+		if !strings.Contains(got.stdout, `source="envi.empty-value"`) || !strings.HasPrefix(got.stdout, "<?xml") {
+			t.Errorf("stdout:\n%s", got.stdout)
+		}
+	})
+
+	t.Run("-format rejects an unknown format", func(t *testing.T) {
+		t.Parallel()
+
+		got := execCLI("", "check", "-format", "yaml", writeFile(t, ".env", "A=1\n"))
+		if got.code != exitFailure || !strings.Contains(got.stderr, `unknown format "yaml"`) {
+			t.Errorf("code %d, stderr %q", got.code, got.stderr)
+		}
+	})
+
+	t.Run("-baseline leaves out known findings", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeFile(t, ".env", "SENTRY_DSN=\nlegacy-name=1\n")
+		dir := filepath.Dir(path)
+		base := filepath.Join(dir, "baseline.json")
+		// The file is named one way here and another below.
+		named := dir + string(filepath.Separator) + "." + string(filepath.Separator) + ".env"
+		if got := execCLI("", "check", "-baseline", base, "-write-baseline", named); got.code != exitOK || got.stdout != "" {
+			t.Fatalf("-write-baseline: code %d, stdout %q, stderr %q", got.code, got.stdout, got.stderr)
+		}
+		want := `{
+  "findings": [
+    {
+      "file": ".env",
+      "rule": "empty-value",
+      "key": "SENTRY_DSN"
+    },
+    {
+      "file": ".env",
+      "rule": "key-not-canonical",
+      "key": "LEGACY_NAME"
+    }
+  ]
+}
+`
+		if got := readFile(t, base); got != want {
+			t.Errorf("baseline\n%swant\n%s", got, want)
+		}
+
+		// A known finding stays known when its line moves; a new one is
+		// reported.
+		if err := os.WriteFile(path, []byte("NEW=\nSENTRY_DSN=\nlegacy-name=1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got := execCLI("", "check", "-strict", "-baseline", base, path)
+		if want := path + ":1: warning: empty-value: value is empty (NEW)\n"; got.code != exitFound || got.stdout != want {
+			t.Errorf("code %d, stdout %q, want %q", got.code, got.stdout, want)
+		}
+
+		if got := execCLI("", "check", "-write-baseline", path); got.code != exitFailure || !strings.Contains(got.stderr, "needs -baseline") {
+			t.Errorf("-write-baseline alone: code %d, stderr %q", got.code, got.stderr)
+		}
+		before := readFile(t, path)
+		if got := execCLI("", "check", "-fix", "-baseline", base, "-write-baseline", path); got.code != exitFailure {
+			t.Errorf("-write-baseline with -fix: code %d", got.code)
+		}
+		if readFile(t, path) != before {
+			t.Error("-write-baseline with -fix rewrote the file")
+		}
+	})
+
+	t.Run("-baseline counts findings without a key", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeFile(t, ".env", "A=1\n!bad\n")
+		base := filepath.Join(filepath.Dir(path), "baseline.json")
+		if got := execCLI("", "check", "-baseline", base, "-write-baseline", path); got.code != exitOK {
+			t.Fatalf("-write-baseline: code %d, stderr %q", got.code, got.stderr)
+		}
+
+		// A second syntax error is new, though the first is known.
+		if err := os.WriteFile(path, []byte("!other\nA=1\n!worse\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got := execCLI("", "check", "-baseline", base, path)
+		if got.code != exitFound || strings.Count(got.stdout, "syntax") != 1 {
+			t.Errorf("code %d, stdout %q: want one syntax error reported", got.code, got.stdout)
+		}
+
+		if got := execCLI("", "check", "-baseline", base, "-write-baseline", path); got.code != exitOK {
+			t.Fatalf("-write-baseline: code %d, stderr %q", got.code, got.stderr)
+		}
+		if got := readFile(t, base); !strings.Contains(got, `"count": 2`) {
+			t.Errorf("baseline does not count the two:\n%s", got)
+		}
+		if got := execCLI("", "check", "-baseline", base, path); got.code != exitOK || got.stdout != "" {
+			t.Errorf("with both known: code %d, stdout %q", got.code, got.stdout)
+		}
+	})
+
 	t.Run("-json on a clean file writes an empty array", func(t *testing.T) {
 		t.Parallel()
 
@@ -316,6 +619,111 @@ func TestUnset(t *testing.T) {
 	})
 }
 
+func TestSwitch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("to a value", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeFile(t, ".env", "A=1\n\n# the database\n#DB_URL='staging'\nDB_URL=prod\n")
+		got := execCLI("", "switch", "-f", path, "db_url", "staging")
+		if got.code != exitOK {
+			t.Fatalf("code = %d: %s", got.code, got.stderr)
+		}
+		if got.stdout != "~ DB_URL: \"prod\" -> \"staging\"\n" {
+			t.Errorf("report = %q", got.stdout)
+		}
+		if want, on := "A=1\n\n# the database\n# DB_URL=prod\nDB_URL=staging\n", readFile(t, path); on != want {
+			t.Errorf("file = %q, want %q", on, want)
+		}
+	})
+
+	t.Run("next", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeFile(t, ".env", "# K=a\n# K=b\nK=c\n")
+		for _, want := range []string{"a", "b", "c"} {
+			if got := execCLI("", "switch", "-f", path, "K", "-next"); got.code != exitOK {
+				t.Fatalf("code = %d: %s", got.code, got.stderr)
+			}
+			if got := execCLI("", "get", "-f", path, "K"); got.stdout != want+"\n" {
+				t.Errorf("K = %q, want %q", got.stdout, want)
+			}
+		}
+		if got := execCLI("", "switch", "-next", "-n", "-f", path, "K"); got.code != exitOK || got.stdout != "# K=b\n# K=c\nK=a\n" {
+			t.Errorf("dry run: code = %d, stdout = %q", got.code, got.stdout)
+		}
+	})
+
+	t.Run("to the live value leaves the file alone", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeFile(t, ".env", "# K=a\nK=a\n")
+		old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+		if got := execCLI("", "switch", "-f", path, "K", "a"); got.code != exitOK || got.stdout != "" {
+			t.Errorf("code = %d, stdout = %q", got.code, got.stdout)
+		}
+		if fi, err := os.Stat(path); err != nil || !fi.ModTime().Equal(old) {
+			t.Errorf("the file was written: %v", err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeFile(t, ".env", "# K=a\nK=b\nL=1\n# M=2\n")
+		for _, args := range [][]string{
+			{"K"},
+			{"K", "a", "-next"},
+			{"-next", "K", "a"},
+			{"K", "zzz"},
+			{"L", "-next"},
+			{"M", "-next"},
+			{"NOPE", "-next"},
+		} {
+			if got := execCLI("", append([]string{"switch", "-f", path}, args...)...); got.code != exitFailure {
+				t.Errorf("%v: code = %d, want %d", args, got.code, exitFailure)
+			}
+		}
+		if on := readFile(t, path); on != "# K=a\nK=b\nL=1\n# M=2\n" {
+			t.Errorf("file = %q, want it unchanged", on)
+		}
+	})
+}
+
+func TestProfile(t *testing.T) {
+	t.Parallel()
+
+	const src = "# DB_URL=staging # @profile=staging\nDB_URL=prod # @profile=prod\n# LOG=debug # @profile=staging\nLOG=info\nPORT=80\n"
+	path := writeFile(t, ".env", src)
+
+	if got := execCLI("", "profile", "-list", "-f", path); got.code != exitOK || got.stdout != "prod\nstaging\n" {
+		t.Errorf("-list: code = %d, stdout = %q", got.code, got.stdout)
+	}
+
+	got := execCLI("", "profile", "-f", path, "staging")
+	if got.code != exitOK {
+		t.Fatalf("code = %d: %s", got.code, got.stderr)
+	}
+	if got.stdout != "~ DB_URL: \"prod\" -> \"staging\"\n~ LOG: \"info\" -> \"debug\"\n" {
+		t.Errorf("report = %q", got.stdout)
+	}
+	want := "# DB_URL=prod # @profile=prod\nDB_URL=staging # @profile=staging\n# LOG=info\nLOG=debug # @profile=staging\nPORT=80\n"
+	if on := readFile(t, path); on != want {
+		t.Errorf("file = %q, want %q", on, want)
+	}
+
+	if got := execCLI("", "profile", "-f", path, "qa"); got.code != exitFailure || !strings.Contains(got.stderr, "no such profile") {
+		t.Errorf("unknown profile: code = %d, stderr = %q", got.code, got.stderr)
+	}
+	if got := execCLI("", "profile", "-f", path); got.code != exitFailure {
+		t.Errorf("no name: code = %d", got.code)
+	}
+}
+
 func TestJSON(t *testing.T) {
 	t.Parallel()
 
@@ -336,6 +744,108 @@ func TestJSON(t *testing.T) {
 	}
 }
 
+func TestJSONFull(t *testing.T) {
+	t.Parallel()
+
+	const src = "# app\nNAME = 'app' # the name\n\n###   ---[ Database ]---   ###\n# DB_HOST=localhost\nDB_HOST=db\n# DB_PASSWORD=x\n"
+	path := writeFile(t, ".env", src)
+	full := execCLI("", "json", "-full", path)
+	if full.code != exitOK {
+		t.Fatalf("code = %d: %s", full.code, full.stderr)
+	}
+	if !strings.Contains(full.stdout, "\"shadows\": [\n") || !strings.Contains(full.stdout, "\"commented\": true") {
+		t.Errorf("stdout:\n%s", full.stdout)
+	}
+
+	back := execCLI(full.stdout, "json", "-decode")
+	if back.code != exitOK || back.stdout != src {
+		t.Errorf("decode: code = %d, stdout = %q, stderr = %s", back.code, back.stdout, back.stderr)
+	}
+
+	edited := strings.Replace(full.stdout, `"value": "db"`, `"value": "db2"`, 1)
+	if got := execCLI(edited, "json", "-decode"); got.stdout != strings.Replace(src, "DB_HOST=db\n", "DB_HOST=db2\n", 1) {
+		t.Errorf("edited: stdout = %q, stderr = %s", got.stdout, got.stderr)
+	}
+
+	if got := execCLI(`{"version": 9}`, "json", "-decode"); got.code != exitFailure || !strings.Contains(got.stderr, "stdin: envi: document: version 9") {
+		t.Errorf("bad version: code = %d, stderr = %q", got.code, got.stderr)
+	}
+	if got := execCLI("", "json", "-full", "-decode"); got.code != exitFailure {
+		t.Errorf("-full -decode: code = %d", got.code)
+	}
+}
+
+func TestJSONTree(t *testing.T) {
+	t.Parallel()
+
+	path := writeFile(t, ".env", "APP_DB_HOST=db\nSERVERS_0_HOST=a\nSERVERS_1_HOST=b\n")
+	got := execCLI("", "json", "-tree", path)
+	if got.code != exitOK {
+		t.Fatalf("code = %d: %s", got.code, got.stderr)
+	}
+	const want = `{
+  "app": {
+    "db": {
+      "host": "db"
+    }
+  },
+  "servers": [
+    {
+      "host": "a"
+    },
+    {
+      "host": "b"
+    }
+  ]
+}
+`
+	if got.stdout != want {
+		t.Errorf("stdout:\n%s\nwant\n%s", got.stdout, want)
+	}
+
+	// import -flatten is the way back.
+	back := writeFile(t, "back.env", "")
+	if got := execCLI(got.stdout, "import", "-flatten", "-f", back); got.code != exitOK {
+		t.Fatalf("import: code = %d: %s", got.code, got.stderr)
+	}
+	if got := readFile(t, back); got != "APP_DB_HOST=db\n\nSERVERS_0_HOST=a\nSERVERS_1_HOST=b\n" {
+		t.Errorf("imported = %q", got)
+	}
+
+	clash := writeFile(t, "clash.env", "APP_DB=x\nAPP_DB_HOST=y\n")
+	if got := execCLI("", "json", "-tree", clash); got.code != exitFailure || !strings.Contains(got.stderr, "APP_DB and APP_DB_HOST collide") {
+		t.Errorf("collision: code = %d, stderr = %q", got.code, got.stderr)
+	}
+	if got := execCLI("", "json", "-tree", "-depth", "1", clash); got.code != exitOK {
+		t.Errorf("-depth 1: code = %d, stderr = %q", got.code, got.stderr)
+	}
+	if got := execCLI("", "json", "-depth", "1", clash); got.code != exitFailure {
+		t.Errorf("-depth without -tree: code = %d", got.code)
+	}
+}
+
+func TestK8s(t *testing.T) {
+	t.Parallel()
+
+	path := writeFile(t, ".env", "DB_PASSWORD=hunter2\n# HIDDEN=x\n")
+	got := execCLI("", "k8s", "-name", "app", "-namespace", "prod", "-secret", path)
+	if got.code != exitOK {
+		t.Fatalf("code = %d: %s", got.code, got.stderr)
+	}
+	if !strings.Contains(got.stdout, "kind: Secret\n") || !strings.Contains(got.stdout, "  DB_PASSWORD: \"aHVudGVyMg==\"\n") ||
+		strings.Contains(got.stdout, "HIDDEN") {
+		t.Errorf("stdout:\n%s", got.stdout)
+	}
+
+	if got := execCLI("", "k8s", path); got.code != exitFailure || !strings.Contains(got.stderr, "-name") {
+		t.Errorf("no name: code = %d, stderr = %q", got.code, got.stderr)
+	}
+	if got := execCLI("", "k8s", "-name", "App", path); got.code != exitFailure || got.stdout != "" ||
+		!strings.Contains(got.stderr, `name "App"`) {
+		t.Errorf("bad name: code = %d, stdout = %q, stderr = %q", got.code, got.stdout, got.stderr)
+	}
+}
+
 func TestExport(t *testing.T) {
 	t.Parallel()
 
@@ -380,6 +890,122 @@ func TestExport(t *testing.T) {
 	})
 }
 
+func TestExportCI(t *testing.T) {
+	t.Parallel()
+
+	path := writeFile(t, ".env", "A=it's \"raw\"\nMULTI=\"one\\ntwo\"\nGITHUB_TOKEN=x\n"+
+		"A.B=dotted\nPADDED=\" x\"\n# HIDDEN=x\n")
+
+	t.Run("github", func(t *testing.T) {
+		t.Parallel()
+
+		got := execCLI("", "export", "-format", "github", path)
+		if got.code != exitOK {
+			t.Fatalf("code = %d: %s", got.code, got.stderr)
+		}
+		lines := strings.Split(got.stdout, "\n")
+		if len(lines) != 8 || lines[0] != `A=it's "raw"` || lines[5] != "A.B=dotted" || lines[6] != "PADDED= x" {
+			t.Fatalf("stdout = %q", got.stdout)
+		}
+		d, ok := strings.CutPrefix(lines[1], "MULTI<<ghadelimiter_")
+		if !ok || lines[2] != "one" || lines[3] != "two" || lines[4] != "ghadelimiter_"+d {
+			t.Errorf("heredoc = %q", lines[1:5])
+		}
+		if again := execCLI("", "export", "-format", "github", path); again.stdout == got.stdout {
+			t.Error("the delimiter is the same on every run")
+		}
+		if !strings.Contains(got.stderr, "GITHUB_TOKEN") {
+			t.Errorf("stderr = %q, want the reserved key named", got.stderr)
+		}
+	})
+
+	t.Run("gitlab", func(t *testing.T) {
+		t.Parallel()
+
+		got := execCLI("", "export", "-format", "gitlab", path)
+		if got.code != exitOK {
+			t.Fatalf("code = %d: %s", got.code, got.stderr)
+		}
+		if want := "A=it's \"raw\"\nGITHUB_TOKEN=x\n"; got.stdout != want {
+			t.Errorf("stdout = %q, want %q", got.stdout, want)
+		}
+		for _, w := range []string{"MULTI: GitLab does not support multi-line", "A.B: GitLab names", "PADDED: GitLab would trim"} {
+			if !strings.Contains(got.stderr, w) {
+				t.Errorf("stderr = %q, want %q", got.stderr, w)
+			}
+		}
+
+		big := writeFile(t, ".env", "A="+strings.Repeat("x", 6000)+"\n")
+		if got := execCLI("", "export", "-format", "gitlab", big); !strings.Contains(got.stderr, "at most 5120") {
+			t.Errorf("oversized report: stderr = %q", got.stderr)
+		}
+	})
+
+	if got := execCLI("", "export", "-format", "yaml", path); got.code != exitFailure {
+		t.Errorf("unknown format: code = %d", got.code)
+	}
+	if got := execCLI("", "export", "-format", "github", "-n", path); got.code != exitFailure {
+		t.Errorf("-n with github: code = %d", got.code)
+	}
+}
+
+func TestExportShells(t *testing.T) {
+	t.Parallel()
+
+	path := writeFile(t, ".env", "A=\"it's \\\"q\\\" \\\\ !x\\nnext\"\nA.B=dot\n")
+	tests := []struct {
+		shell string
+		set   string
+		unset string
+	}{
+		{"posix", "export A='it'\\''s \"q\" \\ !x\nnext'\n", "unset A\n"},
+		{"fish", "set -gx A 'it\\'s \"q\" \\\\ !x\nnext'\n", "set -e A\n"},
+		{"csh", "setenv A 'it'\\''s \"q\" \\ \\!x\\\nnext'\n", "unsetenv A\n"},
+		{
+			"pwsh", "$env:A = 'it''s \"q\" \\ !x\nnext'\n${env:A.B} = 'dot'\n",
+			"Remove-Item -LiteralPath 'Env:A' -ErrorAction SilentlyContinue\n" +
+				"Remove-Item -LiteralPath 'Env:A.B' -ErrorAction SilentlyContinue\n",
+		},
+		{"nu", "$env.A = \"it's \\\"q\\\" \\\\ !x\\nnext\"\n$env.\"A.B\" = \"dot\"\n", "hide-env A\nhide-env \"A.B\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			t.Parallel()
+
+			if got := execCLI("", "export", "-shell", tt.shell, path); got.stdout != tt.set {
+				t.Errorf("set = %q, want %q", got.stdout, tt.set)
+			}
+			if got := execCLI("", "export", "-shell", tt.shell, "-unset", path); got.stdout != tt.unset {
+				t.Errorf("unset = %q, want %q", got.stdout, tt.unset)
+			}
+		})
+	}
+
+	if got := execCLI("", "export", "-shell", "csh", "-n", path); !strings.HasPrefix(got.stdout, "set A = ") {
+		t.Errorf("csh -n = %q", got.stdout)
+	}
+	if got := execCLI("", "export", "-shell", "nu", "-n", path); got.code != exitFailure {
+		t.Errorf("nu -n: code = %d", got.code)
+	}
+	if got := execCLI("", "export", "-shell", "zsh", path); got.code != exitFailure {
+		t.Errorf("unknown shell: code = %d", got.code)
+	}
+	if got := execCLI("", "export", "-format", "gitlab", "-unset", path); got.code != exitFailure {
+		t.Errorf("-unset with gitlab: code = %d", got.code)
+	}
+}
+
+func TestPwshQuote(t *testing.T) {
+	t.Parallel()
+
+	if got, want := pwshQuote("a\u2019b'c"), "'a\u2019\u2019b''c'"; got != want {
+		t.Errorf("pwshQuote = %q, want %q", got, want)
+	}
+	if got, want := nuQuote("\x01\t"), `"\u{1}\t"`; got != want {
+		t.Errorf("nuQuote = %q, want %q", got, want)
+	}
+}
+
 func TestShellQuote(t *testing.T) {
 	t.Parallel()
 
@@ -530,3 +1156,441 @@ func TestFileModeOnCreate(t *testing.T) {
 		t.Errorf("mode = %o, want nothing readable by group or other", got)
 	}
 }
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	if err := os.WriteFile(base, []byte("DB_HOST=localhost\n# OFF=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(local, []byte("\nDB_HOST=db\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got := execCLI("", "explain", "-f", base, "-f", local, "db_host")
+	if got.code != exitOK {
+		t.Fatalf("code = %d: %s", got.code, got.stderr)
+	}
+	want := base + `:1: "localhost" (overridden)` + "\n" + local + `:2: "db"` + "\n"
+	if got.stdout != want {
+		t.Errorf("stdout = %q, want %q", got.stdout, want)
+	}
+
+	if got := execCLI("", "explain", "-f", base, "OFF"); !strings.HasSuffix(got.stdout, "(commented out)\n") {
+		t.Errorf("stdout = %q, want the commented row marked", got.stdout)
+	}
+	if got := execCLI("", "explain", "-f", base, "NOPE"); got.code != exitFound {
+		t.Errorf("code for an absent key = %d, want %d", got.code, exitFound)
+	}
+	if got := execCLI("", "explain", "-f", base); got.code != exitFailure {
+		t.Errorf("code with no key = %d, want %d", got.code, exitFailure)
+	}
+}
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	example := filepath.Join(dir, ".env.example")
+	path := filepath.Join(dir, ".env")
+	if err := os.WriteFile(example, []byte("# the port\nPORT=8080\nNAME=app\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("NAME=mine\nOLD=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := execCLI("", "sync", "-from", example, "-f", path, "-check"); got.code != exitFound {
+		t.Fatalf("check code = %d, want %d: %s", got.code, exitFound, got.stderr)
+	}
+	if got := execCLI("", "sync", "-from", example, "-f", path, "-n"); got.stdout != "NAME=mine\nOLD=1\n# the port\nPORT=8080\n" {
+		t.Errorf("-n stdout = %q", got.stdout)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "NAME=mine\nOLD=1\n" {
+		t.Fatalf("-check or -n wrote the file: %q", b)
+	}
+
+	got := execCLI("", "sync", "-from", example, "-f", path, "-prune")
+	if got.code != exitOK {
+		t.Fatalf("code = %d: %s", got.code, got.stderr)
+	}
+	if want := "- OLD=\"1\"\n+ PORT=\"8080\"\n"; got.stdout != want {
+		t.Errorf("stdout = %q, want %q", got.stdout, want)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "NAME=mine\n# OLD=1\n# the port\nPORT=8080\n" {
+		t.Errorf("file = %q", b)
+	}
+
+	if got := execCLI("", "sync", "-from", example, "-f", path, "-check"); got.code != exitOK || got.stdout != "" {
+		t.Errorf("after sync: code = %d, stdout = %q", got.code, got.stdout)
+	}
+}
+
+func TestMask(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	const src = "APP=shop\nDB_PASSWORD=hunter2\n# @secret\nKEY=k3y\n"
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	next := filepath.Join(dir, "next.env")
+	if err := os.WriteFile(next, []byte("APP=shop\nDB_PASSWORD=swordfish\nKEY=k3y\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"fmt", "-mask", path},
+		{"diff", "-mask", path, next},
+		{"get", "-mask", "-f", path, "DB_PASSWORD"},
+		{"set", "-mask", "-n", "-f", path, "X=1"},
+		{"unset", "-mask", "-n", "-f", path, "APP"},
+		{"export", "-mask", path},
+		{"json", "-mask", path},
+		{"explain", "-mask", "-f", path, "-f", next, "DB_PASSWORD"},
+		{"sync", "-mask", "-n", "-from", next, "-f", path},
+	} {
+		got := execCLI("", args...)
+		if got.code == exitFailure {
+			t.Errorf("%s: code = %d: %s", args[0], got.code, got.stderr)
+		}
+		for _, secret := range []string{"hunter2", "swordfish", "k3y"} {
+			if strings.Contains(got.stdout, secret) {
+				t.Errorf("%s printed %q:\n%s", args[0], secret, got.stdout)
+			}
+		}
+		if !strings.Contains(got.stdout, "******") {
+			t.Errorf("%s printed no mask:\n%s", args[0], got.stdout)
+		}
+	}
+
+	if b, _ := os.ReadFile(path); string(b) != src {
+		t.Errorf("-mask reached the file: %q", b)
+	}
+	if got := execCLI("", "diff", "-mask", path, next); got.stdout != "~ DB_PASSWORD: \"******\" -> \"******\"\n" {
+		t.Errorf("diff -mask = %q", got.stdout)
+	}
+}
+
+// TestEditsListTheirChange holds the commands that edit a file and list what
+// they changed to one behaviour: -n prints the document and moves the list to
+// stderr, and -mask hides secrets in both but never in the file.
+func TestEditsListTheirChange(t *testing.T) {
+	t.Parallel()
+
+	const src = "# DB_PASSWORD=swordfish # @profile=dev\nDB_PASSWORD=hunter2\n"
+	for _, tc := range []struct {
+		name  string
+		stdin string
+		args  []string
+	}{
+		{"switch", "", []string{"switch", "DB_PASSWORD", "swordfish"}},
+		{"profile", "", []string{"profile", "dev"}},
+		{"import", `{"DB_PASSWORD": "swordfish"}`, []string{"import"}},
+		{"sync", "", []string{"sync", "-from"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := writeFile(t, ".env", src)
+			args := tc.args
+			if tc.name == "sync" {
+				args = append(args, writeFile(t, ".env.example", "DB_PASSWORD=\nAPI_TOKEN=swordfish\n"))
+			}
+			args = append([]string{args[0], "-mask", "-f", path}, args[1:]...)
+
+			dry := execCLI(tc.stdin, append([]string{args[0], "-n"}, args[1:]...)...)
+			if dry.code != exitOK {
+				t.Fatalf("-n: code = %d: %s", dry.code, dry.stderr)
+			}
+			if on := readFile(t, path); on != src {
+				t.Errorf("-n wrote the file: %q", on)
+			}
+			if !strings.HasPrefix(dry.stderr, "+ ") && !strings.HasPrefix(dry.stderr, "~ ") {
+				t.Errorf("-n listed no change on stderr: %q", dry.stderr)
+			}
+
+			got := execCLI(tc.stdin, args...)
+			if got.code != exitOK {
+				t.Fatalf("code = %d: %s", got.code, got.stderr)
+			}
+			if got.stdout != dry.stderr {
+				t.Errorf("listed %q, with -n %q", got.stdout, dry.stderr)
+			}
+			for _, out := range []string{dry.stdout, dry.stderr, got.stdout} {
+				if strings.Contains(out, "swordfish") || strings.Contains(out, "hunter2") {
+					t.Errorf("printed a secret:\n%s", out)
+				}
+			}
+			if on := readFile(t, path); !strings.Contains(on, "swordfish") {
+				t.Errorf("-mask reached the file: %q", on)
+			}
+		})
+	}
+}
+
+func TestEncryptDecryptRotate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env.production")
+	oldKey := filepath.Join(dir, "old.key")
+	newKey := filepath.Join(dir, "new.key")
+	const src = "# the database\nDB_HOST=db\n\nDB_PASSWORD=hunter2 # rotate monthly\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{oldKey, newKey} {
+		if got := execCLI("", "keygen", k); got.code != exitOK {
+			t.Fatalf("keygen: code = %d: %s", got.code, got.stderr)
+		}
+	}
+	if got := execCLI("", "keygen", oldKey); got.code != exitFailure {
+		t.Errorf("keygen over an existing key: code = %d", got.code)
+	}
+
+	if got := execCLI("", "encrypt", "-f", path, "-key", oldKey, "DB_PASSWORD"); got.code != exitOK {
+		t.Fatalf("encrypt: code = %d: %s", got.code, got.stderr)
+	}
+	b, _ := os.ReadFile(path)
+	lines := strings.Split(string(b), "\n")
+	if len(lines) != 5 || lines[1] != "DB_HOST=db" || lines[2] != "" ||
+		!strings.HasPrefix(lines[3], "DB_PASSWORD=enc:v1:") || !strings.HasSuffix(lines[3], " # rotate monthly") {
+		t.Errorf("encrypted file:\n%s", b)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o644 {
+		t.Errorf("mode = %v, want it kept", info.Mode().Perm())
+	}
+
+	if got := execCLI("", "rotate", "-f", path, "-key", newKey, "-new-key", oldKey); got.code != exitFailure {
+		t.Errorf("rotate with the wrong key: code = %d", got.code)
+	}
+	if got := execCLI("", "rotate", "-f", path, "-key", oldKey, "-new-key", newKey); got.code != exitOK {
+		t.Fatalf("rotate: code = %d: %s", got.code, got.stderr)
+	}
+
+	if got := execCLI("", "decrypt", "-n", "-f", path, "-key", newKey); got.stdout != src {
+		t.Errorf("decrypt -n = %q, want %q", got.stdout, src)
+	}
+	if got := execCLI("", "decrypt", "-f", path, "-key", oldKey); got.code != exitFailure {
+		t.Errorf("decrypt with the retired key: code = %d", got.code)
+	}
+	if got := execCLI("", "decrypt", "-f", path, "-key", newKey); got.code != exitOK {
+		t.Fatalf("decrypt: code = %d: %s", got.code, got.stderr)
+	}
+	if b, _ := os.ReadFile(path); string(b) != src {
+		t.Errorf("after decrypt: %q, want %q", b, src)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env.production")
+	key := filepath.Join(dir, ".env.key")
+	const src = "# the database\nDB_HOST=db\nDB_PASSWORD=hunter2\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := execCLI("", "keygen", key); got.code != exitOK {
+		t.Fatalf("keygen: code = %d: %s", got.code, got.stderr)
+	}
+
+	if got := execCLI("", "verify", "-key", key, path); got.code != exitFound || !strings.Contains(got.stdout, "no integrity trailer") {
+		t.Errorf("verify unsigned: code = %d, stdout = %q", got.code, got.stdout)
+	}
+	if got := execCLI("", "sign", "-n", "-f", path, "-key", key); got.code != exitOK ||
+		!strings.HasPrefix(got.stdout, src) || !strings.Contains(got.stdout, "# @envi-mac v1 ") {
+		t.Errorf("sign -n: code = %d, stdout = %q", got.code, got.stdout)
+	}
+	if b, _ := os.ReadFile(path); string(b) != src {
+		t.Errorf("sign -n wrote the file: %q", b)
+	}
+
+	if got := execCLI("", "sign", "-f", path, "-key", key); got.code != exitOK {
+		t.Fatalf("sign: code = %d: %s", got.code, got.stderr)
+	}
+	if got := execCLI("", "verify", "-key", key, path); got.code != exitOK {
+		t.Errorf("verify signed: code = %d: %s%s", got.code, got.stdout, got.stderr)
+	}
+	if got := execCLI("", "verify", path); got.code != exitFailure {
+		t.Errorf("verify with no key: code = %d", got.code)
+	}
+
+	if got := execCLI("", "set", "-f", path, "DB_HOST=elsewhere"); got.code != exitOK {
+		t.Fatalf("set: code = %d: %s", got.code, got.stderr)
+	}
+	if got := execCLI("", "verify", "-key", key, path); got.code != exitFound || !strings.Contains(got.stdout, "integrity check failed") {
+		t.Errorf("verify changed: code = %d, stdout = %q", got.code, got.stdout)
+	}
+
+	// A row appended below the trailer: signing again leaves one trailer, last.
+	if err := os.WriteFile(path, []byte(readFile(t, path)+"EXTRA=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := execCLI("", "sign", "-f", path, "-key", key); got.code != exitOK {
+		t.Fatalf("sign again: code = %d: %s", got.code, got.stderr)
+	}
+	if on := readFile(t, path); strings.Count(on, "# @envi-mac v1 ") != 1 || !strings.Contains(on, "EXTRA=1\n# @envi-mac v1 ") {
+		t.Errorf("signed again:\n%s", on)
+	}
+	if got := execCLI("", "verify", "-key", key, path); got.code != exitOK {
+		t.Errorf("verify signed again: code = %d: %s%s", got.code, got.stdout, got.stderr)
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh is not available")
+	}
+	base := writeFile(t, ".env", "GREETING=hello\nPATH=/nowhere\n# HIDDEN=x\n")
+	local := writeFile(t, ".env.local", "GREETING=\"hi there\"\n")
+
+	got := execCLI("", "run", "-f", base, "-f", local, "--", sh, "-c", `printf '%s|%s' "$GREETING" "${HIDDEN-unset}"`)
+	if got.code != exitOK || got.stdout != "hi there|unset" {
+		t.Errorf("run = %d, %q, %q", got.code, got.stdout, got.stderr)
+	}
+
+	// PATH is set in the test's own environment, so it wins unless -override.
+	got = execCLI("", "run", "-f", base, "--", sh, "-c", `printf '%s' "$PATH"`)
+	if got.stdout == "/nowhere" {
+		t.Error("the file overrode a variable already set")
+	}
+	got = execCLI("", "run", "-f", base, "-override", "--", sh, "-c", `printf '%s' "$PATH"`)
+	if got.stdout != "/nowhere" {
+		t.Errorf("-override: PATH = %q", got.stdout)
+	}
+	got = execCLI("", "run", "-f", base, "-clean", "--", sh, "-c", `printf '%s' "${HOME-none}"`)
+	if got.stdout != "none" {
+		t.Errorf("-clean: HOME = %q", got.stdout)
+	}
+
+	if got := execCLI("", "run", "-f", base, "--", sh, "-c", "exit 7"); got.code != 7 {
+		t.Errorf("exit status: code = %d, want 7", got.code)
+	}
+	if got := execCLI("", "run", "-f", base, "--", sh, "-c", "kill -TERM $$"); got.code != 128+15 {
+		t.Errorf("killed by a signal: code = %d, want %d", got.code, 128+15)
+	}
+	// A ^C reaches the command from the terminal; run passes on no second one,
+	// but does pass on a signal sent to it alone.
+	got = execCLI("", "run", "-f", base, "--", sh, "-c", `trap 'echo INT' INT; kill -INT $PPID; sleep 0.3; echo done`)
+	if got.code != exitOK || got.stdout != "done\n" {
+		t.Errorf("SIGINT to run: code = %d, stdout = %q", got.code, got.stdout)
+	}
+	got = execCLI("", "run", "-f", base, "--", sh, "-c", `trap 'echo TERM' TERM; kill -TERM $PPID; sleep 0.3; echo done`)
+	if got.code != exitOK || got.stdout != "TERM\ndone\n" {
+		t.Errorf("SIGTERM to run: code = %d, stdout = %q", got.code, got.stdout)
+	}
+	if got := execCLI("", "run", "-f", base, "--", "envi-no-such-command"); got.code != exitFailure {
+		t.Errorf("missing command: code = %d", got.code)
+	}
+	if got := execCLI("", "run", "-f", base); got.code != exitFailure {
+		t.Errorf("no command: code = %d", got.code)
+	}
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("json into an existing file", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeFile(t, ".env", "# the database\nDB_HOST=old\nDB_PORT=5432\n\nNAME=app\n")
+		got := execCLI(`{"db": {"host": "new", "port": 5432}, "name": "app"}`, "import", "-flatten", "-f", path)
+		if got.code != exitOK {
+			t.Fatalf("code = %d: %s", got.code, got.stderr)
+		}
+		if got.stdout != "~ DB_HOST: \"old\" -> \"new\"\n" {
+			t.Errorf("report = %q", got.stdout)
+		}
+		if got, want := readFile(t, path), "# the database\nDB_HOST=new\nDB_PORT=5432\n\nNAME=app\n"; got != want {
+			t.Errorf("file = %q, want %q", got, want)
+		}
+
+		if got := execCLI(`{"db": {"host": "x"}}`, "import", "-f", path); got.code != exitFailure ||
+			!strings.Contains(got.stderr, "stdin: envi: json: db: a nested object needs flattening") {
+			t.Errorf("nested without -flatten: code = %d, stderr = %q", got.code, got.stderr)
+		}
+	})
+
+	t.Run("an entry with no key", func(t *testing.T) {
+		t.Parallel()
+
+		for _, src := range []string{"a=1\n=foo\n", "a=1\n:bar\n"} {
+			in := writeFile(t, "app.properties", src)
+			got := execCLI("", "import", "-from", "properties", "-n", "-f", "-", in)
+			if got.code != exitFailure || !strings.Contains(got.stderr, `entry 2: the name "" makes no key`) {
+				t.Errorf("%q: code = %d, stdout = %q, stderr = %q", src, got.code, got.stdout, got.stderr)
+			}
+		}
+	})
+
+	t.Run("properties", func(t *testing.T) {
+		t.Parallel()
+
+		in := writeFile(t, "app.properties", "# comment\n! also\ndb.host = localhost\ndb.url: jdbc:x \\\n    ;ssl=true\n"+
+			"greeting Hello\\u0020\\u00e9\\uD83D\\uDE00\\n!\nkey\\ with\\=odd=v\ndb.host=again\n")
+		got := execCLI("", "import", "-from", "properties", "-f", "-", in)
+		if got.code != exitOK {
+			t.Fatalf("code = %d: %s", got.code, got.stderr)
+		}
+		e, err := envi.ParseString(got.stdout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{"DB_HOST": "again", "DB_URL": "jdbc:x ;ssl=true", "GREETING": "Hello é😀\n!", "KEY_WITH_ODD": "v"}
+		for k, v := range want {
+			if got, _ := e.Lookup(k); got != v {
+				t.Errorf("%s = %q, want %q", k, got, v)
+			}
+		}
+		if e.Len() != len(want) {
+			t.Errorf("got %d rows:\n%s", e.Len(), got.stdout)
+		}
+
+		bad := writeFile(t, "bad.properties", "a=\\u12\n")
+		if got := execCLI("", "import", "-from", "properties", "-f", "-", bad); got.code != exitFailure || !strings.Contains(got.stderr, "line 1") {
+			t.Errorf("bad escape: code = %d, stderr = %q", got.code, got.stderr)
+		}
+	})
+
+	t.Run("env-list", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), ".env")
+		got := execCLI("A=1\x00B=x=y\x00MULTI=a\nb\x00", "import", "-from", "env-list", "-f", path)
+		if got.code != exitOK {
+			t.Fatalf("code = %d: %s", got.code, got.stderr)
+		}
+		e, err := envi.ParseString(readFile(t, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := e.Lookup("MULTI"); v != "a\nb" {
+			t.Errorf("MULTI = %q", v)
+		}
+		if v, _ := e.Lookup("B"); v != "x=y" {
+			t.Errorf("B = %q", v)
+		}
+
+		if got := execCLI("A=1\nnot an entry\n", "import", "-from", "env-list", "-n", "-f", path); got.code != exitFailure ||
+			!strings.Contains(got.stderr, "entry 2") {
+			t.Errorf("bad entry: code = %d, stderr = %q", got.code, got.stderr)
+		}
+	})
+
+	if got := execCLI("{}", "import", "-from", "yaml"); got.code != exitFailure {
+		t.Errorf("unknown format: code = %d", got.code)
+	}
+	if got := execCLI("", "import", "-from", "properties", "-flatten"); got.code != exitFailure {
+		t.Errorf("-flatten with properties: code = %d", got.code)
+	}
+}
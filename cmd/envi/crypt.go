@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	envi "github.com/efureev/envi/v2"
+	"github.com/efureev/envi/v2/crypt"
+)
+
+// Where the encryption commands look for a key when no -key flag names one. A
+// passphrase is only ever read from the environment: given as a flag it would
+// sit in the shell history and in the process list.
+const (
+	envKeyFile       = "ENVI_KEY_FILE"
+	envPassphrase    = "ENVI_PASSPHRASE"
+	envNewKeyFile    = "ENVI_NEW_KEY_FILE"
+	envNewPassphrase = "ENVI_NEW_PASSPHRASE"
+)
+
+// errNoKey is what a command needing a key says when there is none.
+var errNoKey = errors.New("no key: give -key FILE, or set " + envKeyFile + " or " + envPassphrase)
+
+// cmdKeygen writes a new random key to a file that must not exist yet:
+//
+//	envi keygen .env.key
+func cmdKeygen(args []string, s ioStreams) int {
+	fs := newFlags("keygen", s)
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+	if fs.NArg() != 1 {
+		return fail(s.err, errors.New("keygen needs exactly one file"))
+	}
+
+	k, err := crypt.GenerateKey()
+	if err != nil {
+		return fail(s.err, err)
+	}
+	if err := crypt.WriteKeyFile(fs.Arg(0), k); err != nil {
+		return fail(s.err, err)
+	}
+	return exitOK
+}
+
+// cmdEncrypt encrypts values in place, every one or the keys named:
+//
+//	envi encrypt -f .env.production -key .env.key
+//
+// Only the value lines change, so the file stays reviewable: which keys exist,
+// what the comments say and how it is laid out are all still in the clear.
+func cmdEncrypt(args []string, s ioStreams) int {
+	return cryptCommand("encrypt", args, s, crypt.Encrypt)
+}
+
+// cmdDecrypt decrypts values in place, every one or the keys named. With -n it
+// prints the plaintext document instead, which is the way to read an encrypted
+// file without leaving it decrypted on disk.
+func cmdDecrypt(args []string, s ioStreams) int {
+	return cryptCommand("decrypt", args, s, crypt.Decrypt)
+}
+
+// cryptCommand is encrypt and decrypt, which differ only in the library call.
+func cryptCommand(name string, args []string, s ioStreams, fn func(*envi.Env, *crypt.Key, ...string) (int, error)) int {
+	fs := newFlags(name, s)
+	path := fs.String("f", defaultFile, "file to edit")
+	keyFile := fs.String("key", "", "key file; defaults to $"+envKeyFile+", then $"+envPassphrase)
+	dry := fs.Bool("n", false, "print the result instead of writing the file")
+	mask := maskFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+
+	k, err := readKey(*keyFile, envKeyFile, envPassphrase)
+	if err != nil {
+		return fail(s.err, err)
+	}
+	e, err := readDoc(*path, s)
+	if err != nil {
+		return fail(s.err, err)
+	}
+	if _, err := fn(e, k, fs.Args()...); err != nil {
+		return fail(s.err, err)
+	}
+	return writeResult(*path, e, *dry, *mask, s)
+}
+
+// cmdRotate re-encrypts every encrypted value under a new key:
+//
+//	envi rotate -f .env.production -key old.key -new-key new.key
+//
+// Nothing is written unless every value opens with the old key.
+func cmdRotate(args []string, s ioStreams) int {
+	fs := newFlags("rotate", s)
+	path := fs.String("f", defaultFile, "file to edit")
+	oldFile := fs.String("key", "", "current key file; defaults to $"+envKeyFile+", then $"+envPassphrase)
+	newFile := fs.String("new-key", "", "new key file; defaults to $"+envNewKeyFile+", then $"+envNewPassphrase)
+	dry := fs.Bool("n", false, "print the result instead of writing the file")
+	mask := maskFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+	if fs.NArg() > 0 {
+		return fail(s.err, errors.New("rotate takes no arguments: every encrypted value moves to the new key"))
+	}
+
+	from, err := readKey(*oldFile, envKeyFile, envPassphrase)
+	if err != nil {
+		return fail(s.err, err)
+	}
+	to, err := readKey(*newFile, envNewKeyFile, envNewPassphrase)
+	if errors.Is(err, errNoKey) {
+		return fail(s.err, errors.New("no new key: give -new-key FILE, or set "+envNewKeyFile+" or "+envNewPassphrase))
+	}
+	if err != nil {
+		return fail(s.err, err)
+	}
+
+	e, err := readDoc(*path, s)
+	if err != nil {
+		return fail(s.err, err)
+	}
+	if _, err := crypt.Rotate(e, from, to); err != nil {
+		return fail(s.err, err)
+	}
+	return writeResult(*path, e, *dry, *mask, s)
+}
+
+// readKey finds a key: the file named by the flag, else the file named by
+// fileVar, else a passphrase in passVar.
+func readKey(path, fileVar, passVar string) (*crypt.Key, error) {
+	if path == "" {
+		path = os.Getenv(fileVar)
+	}
+	if path != "" {
+		return crypt.ReadKeyFile(path)
+	}
+	if pass := os.Getenv(passVar); pass != "" {
+		return crypt.Passphrase(pass)
+	}
+	return nil, errNoKey
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A dialect is how one shell sets and clears a variable.
+type dialect struct {
+	// set returns the statement giving key its value, exported to the
+	// environment of child processes or, with export false, a shell variable
+	// only.
+	set func(key, value string, export bool) string
+
+	// unset returns the statement removing what set created.
+	unset func(key string, export bool) string
+
+	// local reports whether the shell has variables that are not exported,
+	// which is what export -n asks for.
+	local bool
+
+	// name reports whether the shell can name key.
+	name func(key string) bool
+}
+
+// dialects are the shells export writes for, by the name -shell takes.
+var dialects = map[string]dialect{
+	"posix": {
+		set: func(key, value string, export bool) string {
+			if export {
+				return "export " + key + "=" + shellQuote(value)
+			}
+			return key + "=" + shellQuote(value)
+		},
+		unset: func(key string, _ bool) string { return "unset " + key },
+		local: true,
+		name:  isShellName,
+	},
+	"fish": {
+		set: func(key, value string, export bool) string {
+			if export {
+				return "set -gx " + key + " " + fishQuote(value)
+			}
+			return "set -g " + key + " " + fishQuote(value)
+		},
+		unset: func(key string, _ bool) string { return "set -e " + key },
+		local: true,
+		name:  isShellName,
+	},
+	"csh": {
+		set: func(key, value string, export bool) string {
+			if export {
+				return "setenv " + key + " " + cshQuote(value)
+			}
+			return "set " + key + " = " + cshQuote(value)
+		},
+		unset: func(key string, export bool) string {
+			if export {
+				return "unsetenv " + key
+			}
+			return "unset " + key
+		},
+		local: true,
+		name:  isShellName,
+	},
+	"pwsh": {
+		set: func(key, value string, _ bool) string {
+			if isShellName(key) {
+				return "$env:" + key + " = " + pwshQuote(value)
+			}
+			// Braces let a variable name hold what $env:NAME cannot, a dot
+			// among it.
+			return "${env:" + key + "} = " + pwshQuote(value)
+		},
+		unset: func(key string, _ bool) string {
+			return "Remove-Item -LiteralPath " + pwshQuote("Env:"+key) + " -ErrorAction SilentlyContinue"
+		},
+		name: anyName,
+	},
+	"nu": {
+		set: func(key, value string, _ bool) string {
+			return "$env." + nuName(key) + " = " + nuQuote(value)
+		},
+		unset: func(key string, _ bool) string { return "hide-env " + nuName(key) },
+		name:  anyName,
+	},
+}
+
+// shellNames lists the dialects for messages, sorted.
+func shellNames() string {
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+// writeShell writes pairs as statements of the dialect, setting each variable
+// or, with unset, clearing it again.
+func writeShell(s ioStreams, pairs [][2]string, d dialect, export, unset bool) {
+	for _, kv := range pairs {
+		key, value := kv[0], kv[1]
+		if !d.name(key) {
+			// Emitting it would make the whole eval a syntax error, taking the
+			// valid assignments down with it. Saying so beats a silently
+			// incomplete environment.
+			warnf(s.err, "envi: skipping %s: not a usable shell variable name\n", key)
+			continue
+		}
+		if unset {
+			s.out.println(d.unset(key, export))
+		} else {
+			s.out.println(d.set(key, value, export))
+		}
+	}
+}
+
+// anyName accepts every key: the shell quotes what it cannot name bare, and
+// keys are never empty.
+func anyName(string) bool { return true }
+
+// fishQuote wraps a value in single quotes the way fish reads them: a
+// backslash escapes a quote or another backslash and is otherwise literal, and
+// a newline is just a newline.
+func fishQuote(value string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(value) + "'"
+}
+
+// cshQuote wraps a value in single quotes for csh and tcsh, which still expand
+// a history reference inside them and end the command at a bare newline; both
+// take a backslash.
+func cshQuote(value string) string {
+	r := strings.NewReplacer(`'`, `'\''`, "!", `\!`, "\n", "\\\n")
+	return "'" + r.Replace(value) + "'"
+}
+
+// pwshQuote wraps a value in single quotes for PowerShell, where a quote is
+// escaped by doubling it. PowerShell takes the typographic single quotes for
+// quotes as well, so those are doubled too.
+func pwshQuote(value string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range value {
+		switch r {
+		case '\'', '‘', '’', '‚', '‛':
+			b.WriteRune(r)
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// nuQuote writes a value as a Nushell double-quoted string. Nushell's single
+// quotes have no escape at all, so a value holding one could not be written in
+// them.
+func nuQuote(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u{%x}`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// nuName writes key as a member of $env, quoted when a dot would otherwise
+// split it into a path.
+func nuName(key string) string {
+	if isShellName(key) {
+		return key
+	}
+	return nuQuote(key)
+}
@@ -13,6 +13,7 @@ import (
 func cmdDiff(args []string, s ioStreams) int {
 	fs := newFlags("diff", s)
 	asJSON := fs.Bool("json", false, "write the differences as JSON")
+	mask := maskFlag(fs)
 	if err := fs.Parse(args); err != nil {
 		return exitFailure
 	}
@@ -35,6 +36,9 @@ func cmdDiff(args []string, s ioStreams) int {
 	}
 
 	delta := left.Diff(right)
+	if *mask {
+		delta = delta.Redact(nil, left, right)
+	}
 
 	if *asJSON {
 		if err := delta.JSON(s.out); err != nil {
@@ -14,7 +14,7 @@ import (
 // For unset there is no way to tell a key from a path by looking at it:
 // "envi unset APP_NAME config.env" could mean either, and a rule based on dots
 // or slashes gets "envi unset app_name .env" wrong the other way. Guessing in
-// argument parsing is how a tool deletes the wrong thing, so all three editing
+// argument parsing is how a tool deletes the wrong thing, so all the editing
 // commands say it the same explicit way.
 
 // cmdGet prints one configured value.
@@ -24,6 +24,7 @@ import (
 func cmdGet(args []string, s ioStreams) int {
 	fs := newFlags("get", s)
 	path := fs.String("f", defaultFile, "file to read")
+	mask := maskFlag(fs)
 	if err := fs.Parse(args); err != nil {
 		return exitFailure
 	}
@@ -38,7 +39,7 @@ func cmdGet(args []string, s ioStreams) int {
 		return fail(s.err, err)
 	}
 
-	value, ok := configured(e, keys[0])
+	value, ok := configured(shown(e, *mask), keys[0])
 	if !ok {
 		return exitFound
 	}
@@ -52,6 +53,7 @@ func cmdSet(args []string, s ioStreams) int {
 	fs := newFlags("set", s)
 	path := fs.String("f", defaultFile, "file to edit")
 	dry := fs.Bool("n", false, "print the result instead of writing the file")
+	mask := maskFlag(fs)
 	if err := fs.Parse(args); err != nil {
 		return exitFailure
 	}
@@ -74,7 +76,7 @@ func cmdSet(args []string, s ioStreams) int {
 		e.Set(kv[0], kv[1]).SetCommented(false)
 	}
 
-	return writeResult(*path, e, *dry, s)
+	return writeResult(*path, e, *dry, *mask, s)
 }
 
 // cmdUnset removes keys in place. Removing a key that was not there is not an
@@ -83,6 +85,7 @@ func cmdUnset(args []string, s ioStreams) int {
 	fs := newFlags("unset", s)
 	path := fs.String("f", defaultFile, "file to edit")
 	dry := fs.Bool("n", false, "print the result instead of writing the file")
+	mask := maskFlag(fs)
 	if err := fs.Parse(args); err != nil {
 		return exitFailure
 	}
@@ -100,15 +103,113 @@ func cmdUnset(args []string, s ioStreams) int {
 		e.Delete(k)
 	}
 
-	return writeResult(*path, e, *dry, s)
+	return writeResult(*path, e, *dry, *mask, s)
+}
+
+// cmdSwitch makes one of a key's shadows its live value, demoting the value it
+// replaces to a shadow, so that moving between the values a team keeps under
+// a key is an edit of two lines rather than one by hand:
+//
+//	envi switch -f .env DB_URL postgres://staging
+//	envi switch -f .env DB_URL -next
+//
+// -next rotates: the first shadow goes live and the value it replaces becomes
+// the last, so repeating it visits every value in the order the file lists
+// them. The change is listed as diff lists it; switching to the value already
+// live leaves the file as it is.
+func cmdSwitch(args []string, s ioStreams) int {
+	fs := newFlags("switch", s)
+	path := fs.String("f", defaultFile, "file to edit")
+	next := fs.Bool("next", false, "promote the first shadow, whatever it is")
+	dry := fs.Bool("n", false, "print the result instead of writing the file")
+	mask := maskFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+
+	// Flag parsing stops at the key, and "envi switch KEY -next" is the way
+	// round people write it, so -next is recognised after the key as well.
+	rest := fs.Args()
+	if len(rest) == 2 && rest[1] == "-next" {
+		*next, rest = true, rest[:1]
+	}
+	switch {
+	case len(rest) == 0 || len(rest) > 2:
+		return fail(s.err, errors.New("switch needs a key, and a value or -next"))
+	case *next == (len(rest) == 2):
+		return fail(s.err, errors.New("switch needs either a value or -next"))
+	}
+
+	e, err := readDoc(*path, s)
+	if err != nil {
+		return fail(s.err, err)
+	}
+	key := envi.NormalizeKey(rest[0])
+	r := e.Get(key)
+	switch {
+	case r == nil:
+		return fail(s.err, fmt.Errorf("%s is not set", key))
+	case r.IsCommented():
+		return fail(s.err, fmt.Errorf("%s is commented out: there is no live value to switch", key))
+	case r.NumShadows() == 0:
+		return fail(s.err, fmt.Errorf("%s has no shadows to switch to", key))
+	}
+
+	old := r.Value()
+	if *next {
+		r.Rotate()
+	} else if !r.Promote(rest[1]) {
+		return fail(s.err, fmt.Errorf("%s has no such shadow", key))
+	}
+
+	delta := envi.New(envi.NewRow(key, old)).Diff(envi.New(envi.NewRow(key, r.Value())))
+	return writeDelta(*path, e, delta, !delta.Empty(), *dry, *mask, s)
+}
+
+// cmdProfile switches a file to a named profile: every key with a shadow
+// labelled "@profile=NAME" in its trailing comment takes that value, and the
+// value it had becomes a shadow, keeping its own label. -list prints the
+// profiles the file knows instead.
+func cmdProfile(args []string, s ioStreams) int {
+	fs := newFlags("profile", s)
+	path := fs.String("f", defaultFile, "file to edit")
+	list := fs.Bool("list", false, "print the profiles the file knows, one per line")
+	dry := fs.Bool("n", false, "print the result instead of writing the file")
+	mask := maskFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+	switch {
+	case *list && fs.NArg() > 0:
+		return fail(s.err, errors.New("-list takes no profile"))
+	case !*list && fs.NArg() != 1:
+		return fail(s.err, errors.New("profile needs exactly one profile name, or -list"))
+	}
+
+	e, err := readDoc(*path, s)
+	if err != nil {
+		return fail(s.err, err)
+	}
+	if *list {
+		for _, name := range e.Profiles() {
+			s.out.println(name)
+		}
+		return exitOK
+	}
+
+	delta, err := e.ActivateProfile(fs.Arg(0))
+	if err != nil {
+		return fail(s.err, err)
+	}
+	return writeDelta(*path, e, delta, !delta.Empty(), *dry, *mask, s)
 }
 
 // writeResult saves an edited document, or prints it when the caller asked not
 // to touch the file. Editing what came from standard input has nowhere to write
-// back to, so it prints too.
-func writeResult(path string, e *envi.Env, dry bool, s ioStreams) int {
+// back to, so it prints too. mask applies to what is printed only.
+func writeResult(path string, e *envi.Env, dry, mask bool, s ioStreams) int {
 	if dry || path == stdinPath {
-		s.out.print(e)
+		s.out.print(shown(e, mask))
 		return exitOK
 	}
 	if err := writeInPlace(path, e); err != nil {
@@ -135,3 +236,27 @@ func parseAssignments(args []string) ([][2]string, error) {
 	}
 	return pairs, nil
 }
+
+// writeDelta saves a document that an edit changed as delta lists, and lists
+// the change as diff does. Printing the document instead, for -n or standard
+// input, moves the list to stderr so that stdout can be redirected into a file.
+// edited says whether the file needs writing at all; one that does not is left
+// alone, timestamp and all. mask applies to what is printed only: see listed.
+func writeDelta(path string, e *envi.Env, delta *envi.Delta, edited, dry, mask bool, s ioStreams, docs ...*envi.Env) int {
+	report := listed(delta, mask, append([]*envi.Env{e}, docs...)...)
+	if dry || path == stdinPath {
+		_ = report.Text(s.err)
+		s.out.print(shown(e, mask))
+		return exitOK
+	}
+	if err := report.Text(s.out); err != nil {
+		return fail(s.err, err)
+	}
+	if !edited {
+		return exitOK
+	}
+	if err := writeInPlace(path, e); err != nil {
+		return fail(s.err, err)
+	}
+	return exitOK
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// cmdExplain prints every statement of a key across the files given, in the
+// order they were read, and marks the one that won.
+//
+//	envi explain -f .env -f .env.local DB_HOST
+//
+// It is the first thing to reach for when a merged configuration holds a value
+// nobody expected: the answer is rarely in the file one is looking at.
+func cmdExplain(args []string, s ioStreams) int {
+	fs := newFlags("explain", s)
+	var paths fileList
+	fs.Var(&paths, "f", "file to read; repeat for several, later ones override earlier ones")
+	mask := maskFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+
+	keys := fs.Args()
+	if len(keys) != 1 {
+		return fail(s.err, errors.New("explain needs exactly one key"))
+	}
+	if len(paths) == 0 {
+		paths = fileList{defaultFile}
+	}
+
+	e, err := envi.Load(paths...)
+	if err != nil {
+		return fail(s.err, err)
+	}
+	e = shown(e, *mask)
+
+	chain := e.Explain(keys[0])
+	if chain == nil {
+		return exitFound
+	}
+	for i, d := range chain {
+		switch {
+		case i < len(chain)-1:
+			s.out.printf("%s (overridden)\n", d)
+		case e.Get(keys[0]).IsCommented():
+			s.out.printf("%s (commented out)\n", d)
+		default:
+			s.out.println(d)
+		}
+	}
+	return exitOK
+}
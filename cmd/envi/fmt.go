@@ -20,6 +20,7 @@ func cmdFmt(args []string, s ioStreams) int {
 	sort := fs.Bool("sort", false, "sort keys as well as grouping them")
 	group := fs.Int("group", 1, "how many keys sharing a prefix make a block")
 	indent := fs.Int("indent", 1, "blank lines after each block")
+	mask := maskFlag(fs)
 	if err := fs.Parse(args); err != nil {
 		return exitFailure
 	}
@@ -67,6 +68,8 @@ func cmdFmt(args []string, s ioStreams) int {
 					return fail(s.err, err)
 				}
 			}
+		case *mask:
+			s.out.print(e.RedactedView(nil))
 		default:
 			s.out.print(after)
 		}
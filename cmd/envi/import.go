@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// cmdImport brings values from another format into a .env file:
+//
+//	aws secretsmanager get-secret-value --secret-id app --query SecretString --output text |
+//		envi import -from json -f .env
+//
+// The input is read from the file given, or from standard input. -from names
+// its format: a JSON object, with -flatten for nested ones; Java properties;
+// or an env list, the KEY=value lines env(1) prints, NUL-separated as env -0
+// prints them or one per line. What it configures is merged into the file
+// through the document model — a changed value rewrites its line, a new key
+// joins its block — so the diff shows what the import changed and nothing
+// else. The changes are listed as diff lists them.
+func cmdImport(args []string, s ioStreams) int {
+	fs := newFlags("import", s)
+	from := fs.String("from", "json", "input format: json, properties or env-list")
+	flatten := fs.Bool("flatten", false, "turn nested JSON objects and arrays into keys such as DB_HOST")
+	path := fs.String("f", defaultFile, "file to update")
+	dry := fs.Bool("n", false, "print the result instead of writing the file")
+	mask := maskFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+	if *flatten && *from != "json" {
+		return fail(s.err, errors.New("-flatten applies to -from json only"))
+	}
+	input := stdinPath
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		input = fs.Arg(0)
+	default:
+		return fail(s.err, errors.New("import takes at most one input; name the file to update with -f"))
+	}
+	if input == stdinPath && *path == stdinPath {
+		return fail(s.err, errors.New("only one side can be standard input"))
+	}
+
+	src, err := readImport(input, *from, *flatten, s)
+	if err != nil {
+		return fail(s.err, err)
+	}
+	e, err := readOrCreate(*path, s)
+	if err != nil {
+		return fail(s.err, err)
+	}
+
+	delta := e.Import(src)
+	return writeDelta(*path, e, delta, !delta.Empty(), *dry, *mask, s)
+}
+
+// readImport reads the input of import in the format named.
+func readImport(path, format string, flatten bool, s ioStreams) (*envi.Env, error) {
+	r, err := openReader(path, s)
+	if err != nil {
+		return nil, err
+	}
+	defer closeReader(r)
+
+	var e *envi.Env
+	switch format {
+	case "json":
+		e, err = envi.ImportJSON(r, flatten)
+	case "properties":
+		e, err = importPairs(r, parseProperties)
+	case "env-list":
+		e, err = importPairs(r, parseEnvList)
+	default:
+		return nil, fmt.Errorf("unknown format %q: want json, properties or env-list", format)
+	}
+	if err != nil {
+		if path == stdinPath {
+			path = "stdin"
+		}
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return e, nil
+}
+
+// importPairs builds a document from pairs read by parse. A key stated twice
+// takes its last value, as it would in the program that reads the input; a key
+// that normalises to nothing, such as the one of a properties line "=x", is an
+// error.
+func importPairs(r io.Reader, parse func([]byte) ([][2]string, error)) (*envi.Env, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, len(pairs))
+	for i, kv := range pairs {
+		k := envi.NormalizeKey(kv[0])
+		if k == "" {
+			return nil, fmt.Errorf("entry %d: the name %q makes no key", i+1, kv[0])
+		}
+		m[k] = kv[1]
+	}
+	return envi.FromMap(m), nil
+}
+
+// parseEnvList reads KEY=value entries, one per line or NUL-separated. The
+// value is everything after the first "=", taken as it is: there is no quoting
+// in this format.
+func parseEnvList(data []byte) ([][2]string, error) {
+	sep := byte('\n')
+	if bytes.IndexByte(data, 0) >= 0 {
+		sep = 0
+	}
+	var pairs [][2]string
+	for i, entry := range bytes.Split(data, []byte{sep}) {
+		line := strings.TrimSuffix(string(entry), "\r")
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("entry %d: expected KEY=value, got %q", i+1, line)
+		}
+		pairs = append(pairs, [2]string{key, value})
+	}
+	return pairs, nil
+}
+
+// parseProperties reads a Java properties file the way java.util.Properties
+// does: "key=value", "key: value" or "key value", # and ! comments, a trailing
+// backslash continuing a line, and backslash escapes including \uXXXX. The
+// file is read as UTF-8.
+//
+// A dot in a key becomes an underscore, which is the name Spring and most
+// other readers give db.host in the environment: DB_HOST.
+func parseProperties(data []byte) ([][2]string, error) {
+	var pairs [][2]string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, len(data)+1)
+	n := 0
+	for sc.Scan() {
+		n++
+		line := strings.TrimLeft(sc.Text(), " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		start := n
+		for continues(line) && sc.Scan() {
+			n++
+			line = line[:len(line)-1] + strings.TrimLeft(sc.Text(), " \t\f")
+		}
+		if continues(line) {
+			line = line[:len(line)-1]
+		}
+
+		key, value := splitProperty(line)
+		k, err := unescapeProperty(key)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", start, err)
+		}
+		v, err := unescapeProperty(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", start, err)
+		}
+		pairs = append(pairs, [2]string{strings.ReplaceAll(k, ".", "_"), v})
+	}
+	return pairs, sc.Err()
+}
+
+// continues reports whether a properties line ends in an unescaped backslash.
+func continues(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitProperty divides a logical properties line into its raw key and value.
+// The key ends at the first unescaped '=', ':' or whitespace; whitespace and
+// one separator after it belong to neither.
+func splitProperty(line string) (key, value string) {
+	i := 0
+	for ; i < len(line); i++ {
+		c := line[i]
+		if c == '\\' {
+			i++
+			continue
+		}
+		if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+			break
+		}
+	}
+	key = line[:min(i, len(line))]
+	rest := strings.TrimLeft(line[min(i, len(line)):], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return key, rest
+}
+
+// unescapeProperty resolves the backslash escapes of a properties key or value.
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			r, ok := hex4(s[i+1:])
+			if !ok {
+				return "", errors.New(`malformed \uXXXX escape`)
+			}
+			i += 4
+			// Java strings are UTF-16, so a character outside the BMP is
+			// written as two escapes, a surrogate pair.
+			if utf16.IsSurrogate(r) && strings.HasPrefix(s[i+1:], `\u`) {
+				if lo, ok := hex4(s[i+3:]); ok {
+					if pair := utf16.DecodeRune(r, lo); pair != utf8.RuneError {
+						r = pair
+						i += 6
+					}
+				}
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// hex4 reads the four hex digits of a \uXXXX escape from the start of s.
+func hex4(s string) (rune, bool) {
+	if len(s) < 4 {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(s[:4], 16, 16)
+	return rune(n), err == nil
+}
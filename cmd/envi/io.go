@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	envi "github.com/efureev/envi/v2"
 )
@@ -51,6 +52,40 @@ func pathArg(args []string) (string, error) {
 	}
 }
 
+// maskFlag registers -mask, which every command printing values takes.
+func maskFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("mask", false, "hide secret values: *_PASSWORD, *_SECRET, *_TOKEN and rows marked @secret")
+}
+
+// shown returns the document as a command may print it, with secret values
+// masked when -mask asked for that. It never goes to a file: masking is for
+// what lands in a terminal, a CI log or a pasted review.
+func shown(e *envi.Env, mask bool) *envi.Env {
+	if !mask {
+		return e
+	}
+	return e.RedactedView(nil)
+}
+
+// listed returns a change as a command may print it, with the values of keys
+// secret in any of docs masked when -mask asked for that.
+func listed(d *envi.Delta, mask bool, docs ...*envi.Env) *envi.Delta {
+	if !mask {
+		return d
+	}
+	return d.Redact(nil, docs...)
+}
+
+// fileList collects a flag that may be given more than once, in order.
+type fileList []string
+
+func (f *fileList) String() string { return strings.Join(*f, ",") }
+
+func (f *fileList) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
 // readDoc parses one document, from a file or from standard input.
 func readDoc(path string, s ioStreams, opts ...envi.Option) (*envi.Env, error) {
 	if path == stdinPath {
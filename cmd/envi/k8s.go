@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/efureev/envi/v2/k8s"
+)
+
+// cmdK8s prints what a file configures as a Kubernetes manifest:
+//
+//	envi k8s -name app -namespace prod .env | kubectl apply -f -
+//	envi k8s -name app -secret .env.production
+//
+// Nothing is printed unless the manifest is valid: a key Kubernetes would
+// refuse is reported, with the rule it breaks, before kubectl gets to see it.
+func cmdK8s(args []string, s ioStreams) int {
+	fs := newFlags("k8s", s)
+	name := fs.String("name", "", "object name (required)")
+	namespace := fs.String("namespace", "", "object namespace; left out when empty")
+	secret := fs.Bool("secret", false, "write a Secret with base64 data instead of a ConfigMap")
+	annotations := fs.Bool("annotations", false, "carry row comments into envi/KEY annotations")
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+	if *name == "" {
+		return fail(s.err, errors.New("k8s needs -name"))
+	}
+
+	path, err := pathArg(fs.Args())
+	if err != nil {
+		return fail(s.err, err)
+	}
+	e, err := readDoc(path, s)
+	if err != nil {
+		return fail(s.err, err)
+	}
+
+	m := k8s.Manifest{Name: *name, Namespace: *namespace, Secret: *secret, Annotations: *annotations}
+	if err := k8s.Encode(s.out, e, m); err != nil {
+		return fail(s.err, err)
+	}
+	return exitOK
+}
@@ -18,25 +18,49 @@
 //	get      print one configured value
 //	set      set keys in place, leaving the rest of the file alone
 //	unset    remove keys in place
+//	switch   make one of a key's shadows its live value
+//	profile  switch every key to its value in a named profile
 //	export   print shell statements for eval "$(envi export .env)"
-//	json     print the configuration as a JSON object
+//	run      run a command with what the files configure in its environment
+//	json     print the configuration as JSON, or with -full the whole document
+//	k8s      print the configuration as a Kubernetes ConfigMap or Secret
+//	explain  show which file each statement of a key came from
+//	sync     add the keys a file lacks from its .env.example
+//	import   merge values from JSON, Java properties or an env list into a file
+//	encrypt  encrypt values in place, leaving keys and comments readable
+//	decrypt  decrypt values in place, or print them with -n
+//	rotate   re-encrypt every encrypted value under a new key
+//	keygen   write a new random key file
+//	sign     write an integrity trailer vouching for what a file configures
+//	verify   check the integrity trailer of files
 //
 // With no file argument a command reads ".env", the same default [envi.Load]
 // takes. A file argument of "-" means standard input.
 //
 // # What "configured" means
 //
-// A commented-out row configures nothing, so get, export, json and diff pass
-// over it. That is the view [envi.Env.Export] takes, and deliberately not the
-// one [envi.Env.Lookup] takes, which hands back a commented row's value: a
+// A commented-out row configures nothing, so get, export, json, k8s and diff
+// pass over it. That is the view [envi.Env.Export] takes, and deliberately not
+// the one [envi.Env.Lookup] takes, which hands back a commented row's value: a
 // script asking for a key must not be given a value the process will never see.
 //
+// # Secrets
+//
+// Every command that prints values takes -mask, which shows the values of keys
+// ending in _PASSWORD, _SECRET or _TOKEN, and of rows whose comment says
+// @secret, as ******. It changes what is printed, never what is written to a
+// file, so "envi diff -mask prod.env next.env" is safe to paste into a review.
+//
 // # Exit codes
 //
 //	0  nothing to report
 //	1  found what it was asked to look for: check found an error, diff found a
-//	   difference, fmt -check found an unformatted file, get found no value
+//	   difference, fmt -check found an unformatted file, get or explain found no
+//	   value, sync -check found the file out of sync, verify found a file
+//	   unsigned or changed since it was signed
 //	2  the command could not run: bad usage, missing file, unreadable input
+//
+// run is the exception: it exits with the status of the command it ran.
 package main
 
 import (
@@ -93,10 +117,36 @@ func dispatch(args []string, s ioStreams) int {
 		return cmdSet(rest, s)
 	case "unset":
 		return cmdUnset(rest, s)
+	case "switch":
+		return cmdSwitch(rest, s)
+	case "profile":
+		return cmdProfile(rest, s)
 	case "export":
 		return cmdExport(rest, s)
+	case "run":
+		return cmdRun(rest, s)
 	case "json":
 		return cmdJSON(rest, s)
+	case "k8s":
+		return cmdK8s(rest, s)
+	case "explain":
+		return cmdExplain(rest, s)
+	case "sync":
+		return cmdSync(rest, s)
+	case "import":
+		return cmdImport(rest, s)
+	case "encrypt":
+		return cmdEncrypt(rest, s)
+	case "decrypt":
+		return cmdDecrypt(rest, s)
+	case "rotate":
+		return cmdRotate(rest, s)
+	case "keygen":
+		return cmdKeygen(rest, s)
+	case "sign":
+		return cmdSign(rest, s)
+	case "verify":
+		return cmdVerify(rest, s)
 	case "help", "-h", "--help":
 		usage(s.out)
 		return exitOK
@@ -131,17 +181,32 @@ commands:
   get      print one configured value
   set      set keys in place, leaving the rest of the file alone
   unset    remove keys in place
+  switch   make one of a key's shadows its live value
+  profile  switch every key to its value in a named profile
   export   print shell statements for eval "$(envi export .env)"
-  json     print the configuration as a JSON object
+  run      run a command with what the files configure in its environment
+  json     print the configuration as JSON, or with -full the whole document
+  k8s      print the configuration as a Kubernetes ConfigMap or Secret
+  explain  show which file each statement of a key came from
+  sync     add the keys a file lacks from its .env.example
+  import   merge values from JSON, Java properties or an env list into a file
+  encrypt  encrypt values in place, leaving keys and comments readable
+  decrypt  decrypt values in place, or print them with -n
+  rotate   re-encrypt every encrypted value under a new key
+  keygen   write a new random key file
+  sign     write an integrity trailer vouching for what a file configures
+  verify   check the integrity trailer of files
   version  print the version
 
 With no file argument a command reads ".env". A file of "-" means stdin.
-A commented-out row configures nothing, so get, export, json and diff skip it.
+A commented-out row configures nothing, so get, export, json, k8s and diff skip
+it.
 
 exit codes:
   0  nothing to report
   1  found what it was asked to look for
   2  the command could not run
+run exits with the status of the command it ran.
 
 Run "envi <command> -h" for the flags of one command.
 `
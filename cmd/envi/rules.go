@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// A patternRule is one policy from a -rules file: the values of the keys it
+// covers must, or must not, look like a pattern.
+//
+//	{
+//	  "rules": [
+//	    {"name": "url-https", "keys": "*_URL", "match": "https://*",
+//	     "message": "URL is not https"},
+//	    {"name": "no-localhost", "files": "*prod*", "reject": "*localhost*"}
+//	  ]
+//	}
+//
+// Keys are globs in the sense of path.Match, compared in upper case as keys
+// are. Files is matched the same way against the base name of the file being
+// checked. Match and reject are globs over the whole value in which * stands
+// for any run of bytes, slashes included, and ? for any one byte. A rule
+// without keys covers every key, and one without files every file. Severity is
+// "error", the default, or "warning".
+type patternRule struct {
+	Name     string         `json:"name"`
+	Keys     string         `json:"keys,omitempty"`
+	Files    string         `json:"files,omitempty"`
+	Match    string         `json:"match,omitempty"`
+	Reject   string         `json:"reject,omitempty"`
+	Severity *envi.Severity `json:"severity,omitempty"`
+	Message  string         `json:"message,omitempty"`
+}
+
+// loadRules reads a -rules file and checks that every rule can be applied.
+func loadRules(file string) ([]patternRule, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Rules []patternRule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for i, r := range doc.Rules {
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("%s: rule %d: %w", file, i+1, err)
+		}
+	}
+	return doc.Rules, nil
+}
+
+// validate rejects a rule that could never fire or whose globs are malformed,
+// so that a typo in a policy is not mistaken for a clean bill of health.
+func (r patternRule) validate() error {
+	switch {
+	case r.Name == "":
+		return errors.New("no name")
+	case r.Match == "" && r.Reject == "":
+		return fmt.Errorf("%s: neither match nor reject", r.Name)
+	}
+	for _, g := range []string{r.Keys, r.Files} {
+		if _, err := path.Match(g, ""); err != nil {
+			return fmt.Errorf("%s: %q: %w", r.Name, g, err)
+		}
+	}
+	return nil
+}
+
+// info describes the rule for the formats that carry rule metadata.
+func (r patternRule) info() envi.RuleInfo {
+	sev := envi.SeverityError
+	if r.Severity != nil {
+		sev = *r.Severity
+	}
+	summary := r.Message
+	if summary == "" {
+		keys := "every key"
+		if r.Keys != "" {
+			keys = "keys " + r.Keys
+		}
+		switch {
+		case r.Match != "" && r.Reject != "":
+			summary = "The value of " + keys + " does not match " + r.Match + ", or matches " + r.Reject + "."
+		case r.Match != "":
+			summary = "The value of " + keys + " does not match " + r.Match + "."
+		default:
+			summary = "The value of " + keys + " matches " + r.Reject + "."
+		}
+	}
+	return envi.RuleInfo{Rule: envi.Rule(r.Name), Severity: sev, Summary: summary}
+}
+
+// checkersFor returns the rules that apply to the file at p as checkers.
+func checkersFor(rules []patternRule, p string) []envi.Checker {
+	var cs []envi.Checker
+	for _, r := range rules {
+		if r.Files != "" {
+			if ok, _ := filepath.Match(r.Files, filepath.Base(p)); !ok {
+				continue
+			}
+		}
+		cs = append(cs, r)
+	}
+	return cs
+}
+
+// CheckRow implements envi.Checker.
+func (r patternRule) CheckRow(row *envi.Row, line int, _ *envi.Env, rep *envi.Report) {
+	if r.Keys != "" {
+		if ok, _ := path.Match(strings.ToUpper(r.Keys), row.Key()); !ok {
+			return
+		}
+	}
+	v := row.Value()
+	var msg string
+	switch {
+	case r.Match != "" && !globMatch(r.Match, v):
+		msg = "value does not match " + r.Match
+	case r.Reject != "" && globMatch(r.Reject, v):
+		msg = "value matches " + r.Reject
+	default:
+		return
+	}
+	if r.Message != "" {
+		msg = r.Message
+	}
+	rep.Add(envi.Problem{Rule: envi.Rule(r.Name), Severity: r.info().Severity, Line: line, Key: row.Key(), Msg: msg})
+}
+
+// globMatch reports whether s matches pattern as a whole, where * matches any
+// run of bytes and ? any one byte; every other byte stands for itself.
+func globMatch(pattern, s string) bool {
+	// The classic backtracking walk: on a mismatch, let the last * swallow
+	// one more byte and try again from there.
+	p, i := 0, 0
+	star, mark := -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			p++
+			i++
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, i
+			p++
+		case star >= 0:
+			mark++
+			p, i = star+1, mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// forwarded are the signals run passes on to the command rather than acting on
+// itself: those sent to envi alone — by a process supervisor, a container
+// runtime or kill.
+var forwarded = []os.Signal{syscall.SIGTERM, syscall.SIGHUP}
+
+// swallowed are the signals a terminal sends to the whole foreground process
+// group, ^C and ^\, which reach the command without envi's help. run catches
+// them so that they do not end it before the command does, and passes them on
+// no further: to many programs a second ^C means quit now. Caught rather than
+// ignored, they are not ignored by the command either, which an ignored signal
+// would be across exec.
+var swallowed = []os.Signal{os.Interrupt, syscall.SIGQUIT}
+
+// cmdRun runs a command with what the files configure in its environment:
+//
+//	envi run -f .env -f .env.local -- ./server -port 8080
+//
+// Unlike eval "$(envi export)" it leaves the calling shell alone, and it works
+// where there is no shell to eval in: a Makefile recipe, a Procfile, a
+// container entrypoint.
+//
+// The configured rows go over the current environment, with a variable already
+// set keeping its value unless -override; with -clean they are the whole
+// environment. The command is looked up on the current PATH either way.
+//
+// run exits with the command's status, or 128 plus the signal number when a
+// signal ended it, the way a shell reports one. It exits 2 when the command
+// could not be started at all.
+func cmdRun(args []string, s ioStreams) int {
+	fs := newFlags("run", s)
+	var paths fileList
+	fs.Var(&paths, "f", "file to read; repeat for several, later ones override earlier ones")
+	override := fs.Bool("override", false, "let the files override variables already set")
+	clean := fs.Bool("clean", false, "start from an empty environment instead of the current one")
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+	argv := fs.Args()
+	if len(argv) == 0 {
+		return fail(s.err, errors.New("run needs a command: envi run [-f FILE] -- command [args]"))
+	}
+	if len(paths) == 0 {
+		paths = fileList{defaultFile}
+	}
+
+	e, err := envi.Load(paths...)
+	if err != nil {
+		return fail(s.err, err)
+	}
+	var base []string
+	if !*clean {
+		base = os.Environ()
+	}
+
+	cmd := exec.Command(argv[0], argv[1:]...) //nolint:gosec // running the given command is the point
+	// Never nil, even for -clean and an empty file: a nil Env would hand the
+	// command this process's environment after all.
+	cmd.Env = e.Environ(base, *override)
+	// The streams go to the command unwrapped: handed the terminal itself
+	// rather than a pipe, it still knows it is talking to one, and what
+	// becomes of its writes is its own business.
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = s.in, s.out.w, s.err
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwarded...)
+	defer signal.Stop(signals)
+	group := make(chan os.Signal, 1)
+	signal.Notify(group, swallowed...)
+	defer signal.Stop(group)
+
+	if err := cmd.Start(); err != nil {
+		return fail(s.err, err)
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				_ = cmd.Process.Signal(sig)
+			case <-group:
+			case <-done:
+				return
+			}
+		}
+	}()
+	err = cmd.Wait()
+	close(done)
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &exitErr):
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			return 128 + int(ws.Signal())
+		}
+		return exitErr.ExitCode()
+	default:
+		return fail(s.err, err)
+	}
+}
@@ -1,23 +1,55 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"strings"
+
+	envi "github.com/efureev/envi/v2"
 )
 
 // cmdExport prints shell statements that set what the file configures:
 //
 //	eval "$(envi export .env)"
+//	envi export -shell fish .env | source
 //
 // This is what "source .env" is reached for and cannot do. A .env file is not a
 // shell script: an unquoted value holding a space, a hash or a quote either
 // fails to parse or means something else once the shell has had it. Going
 // through the parser and quoting the result properly is the difference.
+//
+// -shell picks the dialect: posix, the default, fish, csh, pwsh or nu. -unset
+// writes the statements clearing every variable the file sets, which undoes an
+// earlier export. -format github and -format gitlab write the env files CI
+// systems read instead: $GITHUB_ENV in GitHub Actions, a dotenv report in
+// GitLab.
 func cmdExport(args []string, s ioStreams) int {
 	fs := newFlags("export", s)
-	noExport := fs.Bool("n", false, "write assignments without the export keyword")
+	format := fs.String("format", "shell", "output format: shell, github or gitlab")
+	shell := fs.String("shell", "posix", "shell dialect: "+shellNames())
+	noExport := fs.Bool("n", false, "write shell variables, not exported ones")
+	unset := fs.Bool("unset", false, "write the statements clearing the variables instead")
+	mask := maskFlag(fs)
 	if err := fs.Parse(args); err != nil {
 		return exitFailure
 	}
+	switch *format {
+	case "shell", "github", "gitlab":
+	default:
+		return fail(s.err, fmt.Errorf("unknown format %q: want shell, github or gitlab", *format))
+	}
+	d, ok := dialects[*shell]
+	if !ok {
+		return fail(s.err, fmt.Errorf("unknown shell %q: want %s", *shell, shellNames()))
+	}
+	if *format != "shell" && (*noExport || *unset || *shell != "posix") {
+		return fail(s.err, errors.New("-shell, -n and -unset apply to -format shell only"))
+	}
+	if *noExport && !d.local {
+		return fail(s.err, fmt.Errorf("-n: %s has no variables but environment ones", *shell))
+	}
 
 	path, err := pathArg(fs.Args())
 	if err != nil {
@@ -28,32 +60,54 @@ func cmdExport(args []string, s ioStreams) int {
 		return fail(s.err, err)
 	}
 
-	keyword := "export "
-	if *noExport {
-		keyword = ""
-	}
-
-	for _, kv := range configuredPairs(e) {
-		key, value := kv[0], kv[1]
-		if !isShellName(key) {
-			// Emitting it would make the whole eval a syntax error, taking the
-			// valid assignments down with it. Saying so beats a silently
-			// incomplete environment.
-			warnf(s.err, "envi: skipping %s: not a usable shell variable name\n", key)
-			continue
-		}
-		s.out.printf("%s%s=%s\n", keyword, key, shellQuote(value))
+	pairs := configuredPairs(shown(e, *mask))
+	switch *format {
+	case "github":
+		writeGitHub(s, pairs)
+	case "gitlab":
+		writeGitLab(s, pairs)
+	default:
+		writeShell(s, pairs, d, !*noExport, *unset)
 	}
 	return exitOK
 }
 
 // cmdJSON prints what the file configures as a JSON object, for jq and for
 // anything else that would rather not parse .env itself.
+//
+// -full prints the whole document instead — blocks, comments, shadows,
+// commented-out rows and the lines as they were written — in the schema of
+// [envi.Env.MarshalDocument]. A program in another language can edit that and
+// hand it to -decode, which reads it from the file named or standard input and
+// prints the .env file it describes: byte for byte the original, but for the
+// edits.
+//
+//	envi json -full .env | jq '.items[0].value = "new"' | envi json -decode > .env.new
+//
+// -tree nests the keys instead, as [envi.Env.Tree] does, for Helm values and
+// the like; import -flatten reads such a tree back.
 func cmdJSON(args []string, s ioStreams) int {
 	fs := newFlags("json", s)
+	mask := maskFlag(fs)
+	full := fs.Bool("full", false, "print the whole document: blocks, comments, shadows and the lines as written")
+	decode := fs.Bool("decode", false, "read a document printed by -full and print the .env file it describes")
+	tree := fs.Bool("tree", false, "nest keys split on _ into objects, and indexed keys into arrays")
+	depth := fs.Int("depth", 0, "with -tree, split a key at most this many times; 0 for no limit")
 	if err := fs.Parse(args); err != nil {
 		return exitFailure
 	}
+	if *full && *decode {
+		return fail(s.err, errors.New("-full and -decode go in opposite directions; give one"))
+	}
+	if *tree && (*full || *decode) {
+		return fail(s.err, errors.New("-tree goes with neither -full nor -decode"))
+	}
+	if *depth != 0 && !*tree {
+		return fail(s.err, errors.New("-depth applies to -tree only"))
+	}
+	if *decode {
+		return decodeDocument(fs.Args(), *mask, s)
+	}
 
 	path, err := pathArg(fs.Args())
 	if err != nil {
@@ -64,6 +118,29 @@ func cmdJSON(args []string, s ioStreams) int {
 		return fail(s.err, err)
 	}
 
+	e = shown(e, *mask)
+
+	if *full {
+		data, err := e.MarshalDocument()
+		if err != nil {
+			return fail(s.err, err)
+		}
+		if err := writeJSON(s.out, json.RawMessage(data)); err != nil {
+			return fail(s.err, err)
+		}
+		return exitOK
+	}
+	if *tree {
+		t, err := e.Tree(*depth)
+		if err != nil {
+			return fail(s.err, err)
+		}
+		if err := writeJSON(s.out, t); err != nil {
+			return fail(s.err, err)
+		}
+		return exitOK
+	}
+
 	// A map, so that encoding/json sorts the keys and the output is the same
 	// every run — which matters the moment it is committed or diffed.
 	out := make(map[string]string, e.Len())
@@ -76,6 +153,38 @@ func cmdJSON(args []string, s ioStreams) int {
 	return exitOK
 }
 
+// decodeDocument is json -decode: it reads a document in the JSON schema from
+// the file named, or from standard input, and prints it as a .env file.
+func decodeDocument(args []string, mask bool, s ioStreams) int {
+	path := stdinPath
+	switch len(args) {
+	case 0:
+	case 1:
+		path = args[0]
+	default:
+		return fail(s.err, fmt.Errorf("expected at most one file, got %d", len(args)))
+	}
+	r, err := openReader(path, s)
+	if err != nil {
+		return fail(s.err, err)
+	}
+	defer closeReader(r)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fail(s.err, err)
+	}
+
+	var e envi.Env
+	if err := e.UnmarshalDocument(data); err != nil {
+		if path == stdinPath {
+			path = "stdin"
+		}
+		return fail(s.err, fmt.Errorf("%s: %w", path, err))
+	}
+	s.out.print(shown(&e, mask))
+	return exitOK
+}
+
 // shellQuote wraps a value in single quotes, which is the only form the shell
 // leaves entirely alone: no expansion, no escapes, nothing special but the
 // closing quote itself.
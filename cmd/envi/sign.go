@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// cmdSign writes an integrity trailer into a file:
+//
+//	envi sign -f .env.production -key .env.key
+//
+// The trailer vouches for every configured row, so a row added, removed or
+// changed afterwards — by hand or by this tool — shows up in verify until the
+// file is signed again.
+func cmdSign(args []string, s ioStreams) int {
+	fs := newFlags("sign", s)
+	path := fs.String("f", defaultFile, "file to sign")
+	keyFile := fs.String("key", "", "key file; defaults to $"+envKeyFile+", then $"+envPassphrase)
+	dry := fs.Bool("n", false, "print the signed document instead of writing the file")
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+	if fs.NArg() > 0 {
+		return fail(s.err, errors.New("sign takes no arguments; name the file with -f"))
+	}
+
+	key, err := readMACKey(*keyFile)
+	if err != nil {
+		return fail(s.err, err)
+	}
+	e, err := readDoc(*path, s)
+	if err != nil {
+		return fail(s.err, err)
+	}
+	if *dry || *path == stdinPath {
+		if err := envi.NewEncoder(s.out, envi.WithSign(key)).Encode(e); err != nil {
+			return fail(s.err, err)
+		}
+		return exitOK
+	}
+	if err := writeInPlace(*path, e, envi.WithSign(key)); err != nil {
+		return fail(s.err, err)
+	}
+	return exitOK
+}
+
+// cmdVerify checks the integrity trailer of each file given, and exits 1 when
+// one is unsigned or no longer matches — the CI gate for a committed,
+// encrypted file.
+func cmdVerify(args []string, s ioStreams) int {
+	fs := newFlags("verify", s)
+	keyFile := fs.String("key", "", "key file; defaults to $"+envKeyFile+", then $"+envPassphrase)
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{defaultFile}
+	}
+
+	key, err := readMACKey(*keyFile)
+	if err != nil {
+		return fail(s.err, err)
+	}
+
+	found := false
+	for _, path := range paths {
+		_, err := readDoc(path, s, envi.WithVerify(key))
+		var ie *envi.IntegrityError
+		switch {
+		case errors.As(err, &ie):
+			s.out.printf("%s: %s\n", path, strings.TrimPrefix(ie.Error(), "envi: "))
+			found = true
+		case err != nil:
+			return fail(s.err, err)
+		}
+	}
+	if found {
+		return exitFound
+	}
+	return exitOK
+}
+
+// readMACKey finds the key a trailer is made with, the same way the encryption
+// commands find theirs. A key file written by keygen serves as the key it
+// holds, and any other file as its text; the library derives the MAC key from
+// either, so it is not the key that encrypts. A passphrase is stretched first,
+// with PBKDF2-HMAC-SHA256 salted with macSalt, as a guess at it should cost
+// what a guess at one for encryption does.
+func readMACKey(path string) ([]byte, error) {
+	if path == "" {
+		path = os.Getenv(envKeyFile)
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		text := strings.TrimSpace(string(data))
+		if text == "" {
+			return nil, errors.New(path + ": key file is empty")
+		}
+		if raw, err := base64.StdEncoding.DecodeString(text); err == nil {
+			return raw, nil
+		}
+		return []byte(text), nil
+	}
+	if pass := os.Getenv(envPassphrase); pass != "" {
+		return pbkdf2.Key(sha256.New, pass, []byte(macSalt), macIterations, sha256.Size)
+	}
+	return nil, errNoKey
+}
+
+// macSalt salts the stretching of a passphrase into a MAC key. It is fixed,
+// since verify must arrive at the key sign used with nothing but the
+// passphrase, and its own, so the result is no key the passphrase encrypts
+// with.
+const macSalt = "envi-mac v1 passphrase"
+
+// macIterations is the work factor for stretching a passphrase, the one package
+// crypt uses.
+const macIterations = 600_000
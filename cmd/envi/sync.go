@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// cmdSync brings a working file in line with the example that documents it:
+//
+//	envi sync -from .env.example -f .env
+//
+// Missing keys are added with the example's value and comment; keys the example
+// no longer mentions are reported, and with -prune commented out. A value the
+// file already holds is never changed. With -check nothing is written and the
+// exit status says whether anything would be, which is the CI gate onboarding
+// needs.
+func cmdSync(args []string, s ioStreams) int {
+	fs := newFlags("sync", s)
+	from := fs.String("from", ".env.example", "the example to sync from")
+	path := fs.String("f", defaultFile, "file to edit")
+	prune := fs.Bool("prune", false, "comment out keys the example no longer has")
+	check := fs.Bool("check", false, "write nothing; exit 1 if the file is out of sync")
+	dry := fs.Bool("n", false, "print the result instead of writing the file")
+	mask := maskFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+	if fs.NArg() > 0 {
+		return fail(s.err, errors.New("sync takes no arguments; name the files with -from and -f"))
+	}
+	if *from == stdinPath && *path == stdinPath {
+		return fail(s.err, errors.New("only one side can be standard input"))
+	}
+
+	template, err := readDoc(*from, s)
+	if err != nil {
+		return fail(s.err, err)
+	}
+	e, err := readOrCreate(*path, s)
+	if err != nil {
+		return fail(s.err, err)
+	}
+
+	delta := e.SyncFrom(template, envi.SyncOptions{Prune: *prune})
+	if *check {
+		if err := listed(delta, *mask, e, template).Text(s.out); err != nil {
+			return fail(s.err, err)
+		}
+		if !delta.Empty() {
+			return exitFound
+		}
+		return exitOK
+	}
+
+	// Keys the example no longer has are only reported without -prune.
+	edited := delta.Count(envi.ChangeAdded) > 0 || (*prune && delta.Count(envi.ChangeRemoved) > 0)
+	return writeDelta(*path, e, delta, edited, *dry, *mask, s, template)
+}
@@ -0,0 +1,144 @@
+// Package crypt encrypts individual values of a .env document, so that a file
+// such as .env.production can be committed with its keys readable and its
+// values not — the way sops works, with no service behind it.
+//
+// An encrypted value reads
+//
+//	DB_PASSWORD=enc:v1:AaU3...
+//
+// and holds AES-256-GCM ciphertext under a key that is either random, kept in
+// a key file outside version control, or derived from a passphrase:
+//
+//	k, err := crypt.ReadKeyFile(".env.key")
+//	env, err := envi.Load(".env.production")
+//	n, err := crypt.Encrypt(env, k)
+//	err = envi.Save(env, ".env.production")
+//
+// Only values change. Keys, comments, blank lines and order stay as they were,
+// so encrypting a file, or rotating its key, shows up in a diff as the value
+// lines alone.
+//
+// The key a value is stored under is authenticated with it: moved to another
+// key, a value no longer decrypts. That stops someone with write access to the
+// file but not the key from swapping, say, a read-only token into the place of
+// an admin one.
+//
+// A service reading an encrypted file decrypts at load time with
+// bind.WithDecryptor, which a [*Key] satisfies.
+package crypt
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// Prefix starts every encrypted value. The version is part of it, so a later
+// format can be told apart and read alongside this one.
+const Prefix = "enc:v1:"
+
+// The first byte of the payload says how the key was made.
+const (
+	kindRaw        = 1
+	kindPassphrase = 2
+)
+
+// Errors reported by this package. Compare with [errors.Is].
+var (
+	// ErrKey reports a key file that does not hold a key, or an empty
+	// passphrase.
+	ErrKey = errors.New("crypt: invalid key")
+
+	// ErrMalformed reports a value that starts with [Prefix] but is not one
+	// this package wrote.
+	ErrMalformed = errors.New("crypt: malformed encrypted value")
+
+	// ErrDecrypt reports a value that does not open with the key given: the
+	// wrong key or passphrase, a value altered since, or one moved to
+	// another key.
+	ErrDecrypt = errors.New("crypt: cannot decrypt value: wrong key, or the value was altered or moved")
+)
+
+// IsEncrypted reports whether value is in the form this package writes.
+func IsEncrypted(value string) bool { return strings.HasPrefix(value, Prefix) }
+
+// Encrypt returns plaintext encrypted for storage under key. Each call draws a
+// fresh nonce, so encrypting one value twice gives two different results.
+func (k *Key) Encrypt(key, plaintext string) (string, error) {
+	var aead cipher.AEAD
+	var head []byte
+	if k.aead != nil {
+		aead = k.aead
+		head = []byte{kindRaw}
+	} else {
+		var err error
+		if aead, err = k.cipherFor(k.salt); err != nil {
+			return "", err
+		}
+		head = append([]byte{kindPassphrase}, k.salt...)
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := append(head, nonce...)
+	out = aead.Seal(out, nonce, []byte(plaintext), additional(key))
+	return Prefix + base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// Decrypt returns the plaintext of a value stored under key. A value that is
+// not encrypted is returned as it is, so a file mixing the two reads through
+// one call; this is what lets a *Key serve as the decryptor of the bind
+// package.
+func (k *Key) Decrypt(key, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(value[len(Prefix):])
+	if err != nil || len(payload) == 0 {
+		return "", ErrMalformed
+	}
+
+	var aead cipher.AEAD
+	rest := payload[1:]
+	switch payload[0] {
+	case kindRaw:
+		if k.aead == nil {
+			return "", ErrDecrypt
+		}
+		aead = k.aead
+	case kindPassphrase:
+		if k.aead != nil {
+			return "", ErrDecrypt
+		}
+		if len(rest) < saltSize {
+			return "", ErrMalformed
+		}
+		if aead, err = k.cipherFor(rest[:saltSize]); err != nil {
+			return "", err
+		}
+		rest = rest[saltSize:]
+	default:
+		return "", ErrMalformed
+	}
+
+	if len(rest) < nonceSize+aead.Overhead() {
+		return "", ErrMalformed
+	}
+	plain, err := aead.Open(nil, rest[:nonceSize], rest[nonceSize:], additional(key))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plain), nil
+}
+
+// additional is the data authenticated alongside a value: the key it is stored
+// under, normalised, so that spelling does not matter and moving does.
+func additional(key string) []byte {
+	return []byte(envi.NormalizeKey(key))
+}
@@ -0,0 +1,197 @@
+package crypt_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	envi "github.com/efureev/envi/v2"
+	"github.com/efureev/envi/v2/crypt"
+)
+
+func mustKey(t *testing.T) *crypt.Key {
+	t.Helper()
+
+	k, err := crypt.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	pass, err := crypt.Passphrase("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, k := range map[string]*crypt.Key{"key": mustKey(t), "passphrase": pass} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, plain := range []string{"hunter2", "", "multi\nline with \"quotes\" and # hash"} {
+				enc, err := k.Encrypt("db_password", plain)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !crypt.IsEncrypted(enc) {
+					t.Fatalf("%q does not carry the prefix", enc)
+				}
+				got, err := k.Decrypt("DB_PASSWORD", enc)
+				if err != nil || got != plain {
+					t.Errorf("Decrypt = %q, %v; want %q", got, err, plain)
+				}
+			}
+		})
+	}
+}
+
+func TestDecryptFailures(t *testing.T) {
+	t.Parallel()
+
+	k := mustKey(t)
+	enc, err := k.Encrypt("A_TOKEN", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := mustKey(t).Decrypt("A_TOKEN", enc); !errors.Is(err, crypt.ErrDecrypt) {
+		t.Errorf("wrong key: err = %v, want ErrDecrypt", err)
+	}
+	if _, err := k.Decrypt("ADMIN_TOKEN", enc); !errors.Is(err, crypt.ErrDecrypt) {
+		t.Errorf("moved value: err = %v, want ErrDecrypt", err)
+	}
+	tampered := enc[:len(enc)-2] + "AA"
+	if tampered == enc {
+		tampered = enc[:len(enc)-2] + "BB"
+	}
+	if _, err := k.Decrypt("A_TOKEN", tampered); !errors.Is(err, crypt.ErrDecrypt) {
+		t.Errorf("tampered value: err = %v, want ErrDecrypt", err)
+	}
+	for _, bad := range []string{crypt.Prefix, crypt.Prefix + "!!", crypt.Prefix + "AQ", crypt.Prefix + "CQ"} {
+		if _, err := k.Decrypt("A_TOKEN", bad); !errors.Is(err, crypt.ErrMalformed) {
+			t.Errorf("Decrypt(%q): err = %v, want ErrMalformed", bad, err)
+		}
+	}
+	if got, err := k.Decrypt("A_TOKEN", "plain"); err != nil || got != "plain" {
+		t.Errorf("plaintext: Decrypt = %q, %v; want it returned as is", got, err)
+	}
+}
+
+func TestKeyFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".env.key")
+	k := mustKey(t)
+	if err := crypt.WriteKeyFile(path, k); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("key file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+	if err := crypt.WriteKeyFile(path, mustKey(t)); !errors.Is(err, os.ErrExist) {
+		t.Errorf("overwriting a key file: err = %v, want ErrExist", err)
+	}
+
+	read, err := crypt.ReadKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, _ := k.Encrypt("K", "v")
+	if got, err := read.Decrypt("K", enc); err != nil || got != "v" {
+		t.Errorf("a key read back does not decrypt: %q, %v", got, err)
+	}
+
+	if _, err := crypt.ParseKey([]byte("c2hvcnQ=")); !errors.Is(err, crypt.ErrKey) {
+		t.Errorf("short key: err = %v, want ErrKey", err)
+	}
+	if _, err := crypt.Passphrase(""); !errors.Is(err, crypt.ErrKey) {
+		t.Errorf("empty passphrase: err = %v, want ErrKey", err)
+	}
+	pass, _ := crypt.Passphrase("p")
+	if _, err := pass.MarshalText(); err == nil {
+		t.Error("a passphrase marshalled as a key")
+	}
+}
+
+func TestEncryptDocumentKeepsLayout(t *testing.T) {
+	t.Parallel()
+
+	const src = "###   ---[ Database ]---   ###\n" +
+		"# where it lives\nDB_HOST=localhost\n\n# rotate monthly\nDB_PASSWORD=\"hunter2\" # prod\n\n" +
+		"# OLD_TOKEN=abc\nEMPTY=\n"
+	e, err := envi.ParseString(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := mustKey(t)
+
+	n, err := crypt.Encrypt(e, k, "db_password", "OLD_TOKEN", "EMPTY")
+	if err != nil || n != 2 {
+		t.Fatalf("Encrypt = %d, %v; want 2", n, err)
+	}
+	lines := strings.Split(e.String(), "\n")
+	want := strings.Split(src, "\n")
+	for i := range want {
+		switch {
+		case strings.HasPrefix(want[i], "DB_PASSWORD="):
+			if !strings.HasPrefix(lines[i], "DB_PASSWORD="+crypt.Prefix) || !strings.HasSuffix(lines[i], " # prod") {
+				t.Errorf("line %d = %q", i+1, lines[i])
+			}
+		case strings.HasPrefix(want[i], "# OLD_TOKEN="):
+			if !strings.HasPrefix(lines[i], "# OLD_TOKEN="+crypt.Prefix) {
+				t.Errorf("line %d = %q", i+1, lines[i])
+			}
+		case lines[i] != want[i]:
+			t.Errorf("line %d = %q, want %q", i+1, lines[i], want[i])
+		}
+	}
+
+	if n, err := crypt.Encrypt(e, k); err != nil || n != 1 {
+		t.Errorf("encrypting the rest = %d, %v; want only DB_HOST", n, err)
+	}
+	if _, err := crypt.Encrypt(e, k, "NOPE"); err == nil || !strings.Contains(err.Error(), "NOPE") {
+		t.Errorf("absent key: err = %v", err)
+	}
+
+	if n, err := crypt.Decrypt(e, k); err != nil || n != 3 {
+		t.Fatalf("Decrypt = %d, %v; want 3", n, err)
+	}
+	if v, _ := e.Lookup("DB_PASSWORD"); v != "hunter2" {
+		t.Errorf("DB_PASSWORD = %q after the round trip", v)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	t.Parallel()
+
+	from, to := mustKey(t), mustKey(t)
+	e := envi.New(envi.NewRow("A_SECRET", "a"), envi.NewRow("PLAIN", "p"))
+	if _, err := crypt.Encrypt(e, from, "A_SECRET"); err != nil {
+		t.Fatal(err)
+	}
+
+	before := e.String()
+	if _, err := crypt.Rotate(e, to, from); !errors.Is(err, crypt.ErrDecrypt) {
+		t.Errorf("rotating with the wrong key: err = %v", err)
+	}
+	if e.String() != before {
+		t.Error("a failed rotation changed the document")
+	}
+
+	if n, err := crypt.Rotate(e, from, to); err != nil || n != 1 {
+		t.Fatalf("Rotate = %d, %v; want 1", n, err)
+	}
+	if v, _ := e.Lookup("A_SECRET"); v == before || !crypt.IsEncrypted(v) {
+		t.Errorf("A_SECRET = %q", v)
+	}
+	if _, err := crypt.Decrypt(e, from); err == nil {
+		t.Error("the old key still decrypts")
+	}
+	if _, err := crypt.Decrypt(e, to); err != nil {
+		t.Errorf("the new key does not decrypt: %v", err)
+	}
+}
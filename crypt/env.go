@@ -0,0 +1,121 @@
+package crypt
+
+import (
+	"fmt"
+
+	envi "github.com/efureev/envi/v2"
+	"github.com/efureev/envi/v2/internal/rowedit"
+)
+
+// Encrypt encrypts the values of the named keys in e, or of every key when none
+// are named, and returns how many it encrypted. Empty values and values already
+// encrypted are left alone, so running it again over a file that gained a key
+// encrypts just that key.
+//
+// Commented-out rows are encrypted as well: a value commented out is usually a
+// previous secret. Shadows are not, and are best removed from a file before it
+// is committed.
+//
+// A named key the document does not hold is an error, reported before anything
+// changes.
+func Encrypt(e *envi.Env, k *Key, keys ...string) (int, error) {
+	return apply(e, keys, func(r *envi.Row) (bool, error) {
+		if r.Value() == "" || IsEncrypted(r.Value()) {
+			return false, nil
+		}
+		v, err := k.Encrypt(r.Key(), r.Value())
+		if err != nil {
+			return false, err
+		}
+		rowedit.ReplaceValue(r, v)
+		return true, nil
+	})
+}
+
+// Decrypt decrypts the values of the named keys in e, or of every key when none
+// are named, and returns how many it decrypted. Values that are not encrypted
+// are left alone.
+//
+// The first value that does not decrypt stops the walk, and the error names its
+// key; values decrypted before it keep their plaintext, so a document that
+// failed part way should not be saved.
+func Decrypt(e *envi.Env, k *Key, keys ...string) (int, error) {
+	return apply(e, keys, func(r *envi.Row) (bool, error) {
+		if !IsEncrypted(r.Value()) {
+			return false, nil
+		}
+		v, err := k.Decrypt(r.Key(), r.Value())
+		if err != nil {
+			return false, err
+		}
+		rowedit.ReplaceValue(r, v)
+		return true, nil
+	})
+}
+
+// Rotate re-encrypts every encrypted value in e from one key to another and
+// returns how many it rotated. Values that are not encrypted stay as they are.
+// It fails, changing nothing, if any value does not decrypt with from.
+func Rotate(e *envi.Env, from, to *Key) (int, error) {
+	// Decrypt everything first, so that a value the old key cannot open is
+	// found before a single row has been rewritten.
+	type pending struct {
+		row   *envi.Row
+		plain string
+	}
+	var rows []pending
+	for r := range e.Rows() {
+		if !IsEncrypted(r.Value()) {
+			continue
+		}
+		plain, err := from.Decrypt(r.Key(), r.Value())
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", r.Key(), err)
+		}
+		rows = append(rows, pending{row: r, plain: plain})
+	}
+
+	values := make([]string, len(rows))
+	for i, p := range rows {
+		v, err := to.Encrypt(p.row.Key(), p.plain)
+		if err != nil {
+			return 0, err
+		}
+		values[i] = v
+	}
+	for i, p := range rows {
+		rowedit.ReplaceValue(p.row, values[i])
+	}
+	return len(rows), nil
+}
+
+// apply runs fn over the named rows of e, or over all of them, and counts the
+// rows it changed. A failure is reported with the key of the row.
+func apply(e *envi.Env, keys []string, fn func(*envi.Row) (bool, error)) (int, error) {
+	var rows []*envi.Row
+	if len(keys) == 0 {
+		for r := range e.Rows() {
+			rows = append(rows, r)
+		}
+	} else {
+		for _, key := range keys {
+			r := e.Get(key)
+			if r == nil {
+				return 0, fmt.Errorf("crypt: %s: no such key", envi.NormalizeKey(key))
+			}
+			rows = append(rows, r)
+		}
+	}
+
+	n := 0
+	for _, r := range rows {
+		changed, err := fn(r)
+		if err != nil {
+			return n, fmt.Errorf("%s: %w", r.Key(), err)
+		}
+		if changed {
+			n++
+		}
+	}
+	return n, nil
+}
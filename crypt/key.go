@@ -0,0 +1,164 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Sizes of the pieces of a key and of an encrypted value.
+const (
+	keySize   = 32 // AES-256
+	saltSize  = 16
+	nonceSize = 12 // the standard GCM nonce
+)
+
+// pbkdf2Iterations is the work factor for a passphrase, the figure OWASP gives
+// for PBKDF2-HMAC-SHA256. It is paid once per salt, not once per value.
+var pbkdf2Iterations = 600_000
+
+// A Key encrypts and decrypts values. It is either a random key, usually kept
+// in a key file, or one derived from a passphrase.
+//
+// A Key is safe for concurrent use.
+type Key struct {
+	// raw is a random key and aead its cipher; both are nil for a
+	// passphrase.
+	raw  []byte
+	aead cipher.AEAD
+
+	// pass is the passphrase of a derived key, and salt the salt values it
+	// encrypts are derived with. One salt for every value a Key encrypts is
+	// what keeps the derivation — slow on purpose — to one per run.
+	pass string
+	salt []byte
+
+	// derived caches the cipher for each salt met while decrypting. A file
+	// encrypted in one run holds one salt, so it costs one derivation.
+	mu      sync.Mutex
+	derived map[string]cipher.AEAD
+}
+
+// GenerateKey returns a new random key. Keep it with [WriteKeyFile], out of
+// version control.
+func GenerateKey() (*Key, error) {
+	raw := make([]byte, keySize)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	return newRawKey(raw)
+}
+
+// newRawKey wraps a random key.
+func newRawKey(raw []byte) (*Key, error) {
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{raw: raw, aead: aead}, nil
+}
+
+// newAEAD returns AES-GCM keyed with raw.
+func newAEAD(raw []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ParseKey reads a key in the form [Key.MarshalText] writes: 32 bytes in
+// standard base64, surrounding white space ignored.
+func ParseKey(text []byte) (*Key, error) {
+	raw, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(text)))
+	if err != nil || len(raw) != keySize {
+		return nil, ErrKey
+	}
+	return newRawKey(raw)
+}
+
+// ReadKeyFile reads a key file written by [WriteKeyFile].
+func ReadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	k, err := ParseKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return k, nil
+}
+
+// WriteKeyFile writes k to a new file at path, readable by its owner only. It
+// refuses to overwrite an existing file: replacing a key loses every value
+// encrypted with it.
+func WriteKeyFile(path string, k *Key) error {
+	text, err := k.MarshalText()
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(text, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Passphrase returns a key derived from pass with PBKDF2-HMAC-SHA256. Every
+// value it encrypts carries the random salt, so the passphrase alone decrypts
+// it. An empty passphrase is [ErrKey].
+func Passphrase(pass string) (*Key, error) {
+	if pass == "" {
+		return nil, ErrKey
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return &Key{pass: pass, salt: salt}, nil
+}
+
+// MarshalText writes a random key as standard base64, the content of a key
+// file. A passphrase has no such form and is an error.
+func (k *Key) MarshalText() ([]byte, error) {
+	if k.raw == nil {
+		return nil, errors.New("crypt: a passphrase cannot be written out as a key")
+	}
+	return []byte(base64.StdEncoding.EncodeToString(k.raw)), nil
+}
+
+// cipherFor returns the cipher for a salt, deriving and caching it.
+func (k *Key) cipherFor(salt []byte) (cipher.AEAD, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if aead, ok := k.derived[string(salt)]; ok {
+		return aead, nil
+	}
+	raw, err := pbkdf2.Key(sha256.New, k.pass, salt, pbkdf2Iterations, keySize)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+	if k.derived == nil {
+		k.derived = make(map[string]cipher.AEAD)
+	}
+	k.derived[string(salt)] = aead
+	return aead, nil
+}
@@ -35,7 +35,8 @@ func NewDecoder(r io.Reader, opts ...Option) *Decoder {
 // Decode reads one document from the stream.
 //
 // A malformed line stops the read and is reported as a [*SyntaxError] carrying
-// its position.
+// its position. Under [WithVerify] a document its integrity trailer does not
+// vouch for is reported as an [*IntegrityError].
 func (d *Decoder) Decode() (*Env, error) {
 	b := newBuilder(d.cfg, d.report)
 	var info lineInfo
@@ -64,6 +65,11 @@ func (d *Decoder) Decode() (*Env, error) {
 		return nil, err
 	}
 	env.eol = d.s.eol
+	if d.cfg.verifyKey != nil && d.report == nil {
+		if err := env.Verify(d.cfg.verifyKey); err != nil {
+			return nil, err
+		}
+	}
 	return env, nil
 }
 
@@ -123,6 +129,7 @@ func loadFile(path string, opts []Option) (*Env, error) {
 	if err != nil {
 		return nil, fmt.Errorf("envi: %s: %w", path, err)
 	}
+	e.setFile(path)
 	return e, nil
 }
 
@@ -222,6 +229,7 @@ func (b *builder) emit(info *lineInfo, commented bool) {
 		rawLine:   info.raw,
 		parsed:    true,
 		commented: commented,
+		origin:    Origin{Line: info.line},
 	}
 
 	header, prefix, comment := b.takePending()
@@ -233,6 +241,9 @@ func (b *builder) emit(info *lineInfo, commented bool) {
 		header = b.absorbShadows(r, header)
 	}
 
+	if b.report != nil {
+		b.report.note(r, info.line)
+	}
 	b.check(info, commented)
 
 	// The same key stated twice in one document folds into the row already
@@ -249,6 +260,12 @@ func (b *builder) emit(info *lineInfo, commented bool) {
 				Line:     info.check.line,
 				Key:      r.key,
 				Msg:      "key is already defined on line " + strconv.Itoa(b.seenLine[r.key]) + ", and that value is discarded",
+				Fix: Fix{
+					Op:   FixDropValue,
+					Key:  r.key,
+					Line: b.seenLine[r.key],
+					Msg:  "remove the definition on line " + strconv.Itoa(b.seenLine[r.key]),
+				},
 			})
 		}
 		foldDuplicate(prev, r, commented)
@@ -279,37 +296,89 @@ func (b *builder) check(info *lineInfo, commented bool) {
 			Line:     lc.line,
 			Key:      info.key,
 			Msg:      "key is written as " + strconv.Quote(lc.keyRaw),
+			Fix:      Fix{Op: FixRewrite, Key: info.key, Line: lc.line, Msg: "write the key as " + info.key},
 		})
 	}
 	if lc.bareSpecial != 0 {
+		// Naming the character gives away part of a secret value.
+		msg := "bare value holds " + strconv.QuoteRune(rune(lc.bareSpecial))
+		if b.report.secret(info.key) {
+			msg = "bare value holds a character the shell interprets"
+		}
 		b.report.record(Problem{
 			Rule:     RuleUnquotedValue,
 			Severity: SeverityWarning,
 			Line:     lc.line,
 			Key:      info.key,
-			Msg:      "bare value holds " + strconv.QuoteRune(rune(lc.bareSpecial)),
+			Msg:      msg,
+			Fix:      Fix{Op: quoteOp(lc.bareSpecial), Key: info.key, Line: lc.line, Msg: "quote the value"},
 		})
 	}
 	checkRow(b.report, info.key, info.value, commented, lc.line)
 }
 
+// keepsLines reports whether a live statement displacing another, prev, can
+// keep the lines recorded for both: what the two rows say must be what those
+// lines say once the displaced assignment is written as a shadow.
+func keepsLines(prev, next *Row) bool {
+	return prev.parsed && next.parsed && len(next.shadows) == 0 &&
+		(prev.inline == "" || prev.inline == next.inline) &&
+		(prev.comment == "" || next.comment == "")
+}
+
+// quoteOp returns the fix that quotes a bare value holding c. Rewriting the
+// value quotes it when it has to be, which a space does not make it.
+func quoteOp(c byte) FixOp {
+	if c == ' ' || c == '\t' {
+		return FixQuote
+	}
+	return FixRewrite
+}
+
 // foldDuplicate merges a repeated definition of a key into the row already
 // holding it. A live definition wins the value and demotes what was there to a
 // shadow; a commented one only adds a shadow.
 func foldDuplicate(prev, next *Row, nextCommented bool) {
 	if nextCommented {
-		prev.addParsedShadow(next.value)
+		// The comment trailing a commented statement belongs to its value.
+		prev.addParsedShadow(shadow{value: next.value, inline: next.inline})
 	} else {
-		prev.addParsedShadow(prev.value)
+		demoted := shadow{value: prev.value}
+		if prev.commented {
+			// An inert statement displaced nothing: it only becomes a shadow.
+			prev.origin = next.origin
+		} else {
+			demoted.def = prev.origin.Line
+			if keepsLines(prev, next) {
+				// The displaced statement is written as the shadow it now is,
+				// where it stood, unless the file states that shadow already,
+				// and the lines around both stay.
+				if !prev.HasShadow(demoted.value) {
+					prev.rawPrefix = append(prev.rawPrefix, shadowText(prev.key, demoted))
+				}
+				prev.rawPrefix = append(prev.rawPrefix, next.rawPrefix...)
+				prev.rawLine = next.rawLine
+				prev.supersede(next)
+				prev.addParsedShadow(demoted)
+				prev.value = next.value
+				prev.inline = next.inline
+				if prev.comment == "" {
+					prev.comment = next.comment
+				}
+				return
+			}
+			prev.supersede(next)
+		}
+		prev.addParsedShadow(demoted)
 		prev.value = next.value
 		prev.commented = false
+		if prev.inline == "" {
+			prev.inline = next.inline
+		}
 	}
 	if prev.comment == "" {
 		prev.comment = next.comment
 	}
-	if prev.inline == "" {
-		prev.inline = next.inline
-	}
 	for _, s := range next.shadows {
 		prev.addParsedShadow(s)
 	}
@@ -318,14 +387,21 @@ func foldDuplicate(prev, next *Row, nextCommented bool) {
 	// commented, and such a row's shadows are written below it, so the lines
 	// recorded above it still describe exactly what precedes them. Keeping
 	// those lines is what stops a blank line or a comment above the first
-	// statement from disappearing, since no other row records it. The
-	// assignment itself has to be re-rendered, because reproducing the one
-	// recorded line would state only the first of the two.
+	// statement from disappearing, since no other row records it. The lines of
+	// the second statement are recorded below, where its shadow is written,
+	// so the pair still reads back byte for byte — and still does once a live
+	// statement below absorbs it. The assignment has to be re-rendered only if
+	// either statement had already lost its lines.
 	//
 	// Any other fold hands the row a shadow written above it, where its
 	// recorded lines may already hold one absorbed from there, and writing both
 	// would say it twice. Then the whole rendering has to go.
 	if nextCommented && prev.commented {
+		if prev.parsed && next.parsed && next.rawLine != "" && len(next.shadows) == 0 {
+			prev.rawBelow = append(prev.rawBelow, next.rawPrefix...)
+			prev.rawBelow = append(prev.rawBelow, next.rawLine)
+			return
+		}
 		prev.dropLine()
 		return
 	}
@@ -365,18 +441,16 @@ func (b *builder) absorbShadows(r *Row, header *headerInfo) *headerInfo {
 		} else {
 			prefix = append(prefix, a.row.rawPrefix...)
 			prefix = append(prefix, a.row.rawLine)
+			prefix = append(prefix, a.row.rawBelow...)
 		}
 
-		r.addParsedShadow(a.row.value)
+		r.addParsedShadow(a.row.demoted())
 		for _, s := range a.row.shadows {
 			r.addParsedShadow(s)
 		}
 		if a.row.comment != "" {
 			comments = append(comments, a.row.comment)
 		}
-		if r.inline == "" {
-			r.inline = a.row.inline
-		}
 		if header == nil && a.header != nil {
 			header = a.header
 		}
@@ -19,14 +19,20 @@
 //	# REDIS_HOST=redis
 //	REDIS_HOST=127.0.0.1
 //
-// Here REDIS_HOST has one shadow, redis.
+// Here REDIS_HOST has one shadow, redis. [Row.ShadowList] describes each
+// shadow with the comment trailing it, and [Row.Promote] swaps one with the
+// live value. A comment trailing a value travels with it, and "@profile=NAME"
+// there puts the value in a profile that [Env.ActivateProfile] switches the
+// whole document to.
 //
 // # Reading and writing
 //
 // [Parse], [ParseBytes], [ParseString] and [Load] read documents; [Env.WriteTo],
 // [Env.MarshalText] and [Save] write them. Both directions accept the same
 // [Option] values, so a document can be re-encoded with different formatting
-// without being re-read.
+// without being re-read. [Env.MarshalDocument] and [Env.UnmarshalDocument]
+// carry the whole model through JSON instead, for a program in another
+// language to edit without losing the file's shape.
 //
 // # Arranging a document
 //
@@ -43,7 +49,9 @@
 // problem into a [Report] instead of stopping at the first malformed line the
 // way [Parse] does. [Env.Check] runs over a document already in memory the
 // rules that need no source text. Individual rules switch off with
-// [WithoutRules].
+// [WithoutRules], and rules of the caller's own, each a [Checker], are added
+// with [WithCheckers]. A comment carrying [IgnoreMarker] switches rules off
+// for the one row it belongs to.
 //
 // # Comparing documents
 //
@@ -0,0 +1,465 @@
+package envi
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DocumentVersion is the version of the JSON schema [Env.MarshalDocument]
+// writes, and the only one [Env.UnmarshalDocument] accepts.
+const DocumentVersion = 1
+
+// The JSON document: the whole model of an [Env], for a program in another
+// language to read and edit. Field names are part of the schema, so changing
+// one means a new [DocumentVersion].
+type (
+	docJSON struct {
+		Version int        `json:"version"`
+		EOL     string     `json:"eol,omitempty"`
+		Items   []itemJSON `json:"items"`
+		Trailer []string   `json:"trailer,omitempty"`
+	}
+
+	// itemJSON is one item, a row or a block, told apart by "type".
+	itemJSON struct {
+		row   *rowJSON
+		block *blockJSON
+	}
+
+	rowJSON struct {
+		Key       string       `json:"key"`
+		Value     string       `json:"value"`
+		Comment   string       `json:"comment,omitempty"`
+		Inline    string       `json:"inline,omitempty"`
+		Shadows   []shadowJSON `json:"shadows,omitempty"`
+		Commented bool         `json:"commented,omitempty"`
+		Raw       *rowRaw      `json:"raw,omitempty"`
+	}
+
+	// shadowJSON is a shadow: its value as a string, or an object holding the
+	// value and the comment trailing it when there is one.
+	shadowJSON shadow
+
+	// rowRaw holds the lines as they were read: those above the assignment,
+	// the assignment itself while it still says what the row does, and below
+	// a commented row the lines stating its shadows.
+	//
+	// sum records what the lines say, so that an editor changing a field
+	// without touching them is noticed: see [rawSum].
+	rowRaw struct {
+		Above []string `json:"above,omitempty"`
+		Line  string   `json:"line,omitempty"`
+		Below []string `json:"below,omitempty"`
+		Sum   string   `json:"sum,omitempty"`
+	}
+
+	blockJSON struct {
+		Prefix  string    `json:"prefix"`
+		Comment string    `json:"comment,omitempty"`
+		Rows    []rowJSON `json:"rows"`
+		Blanks  *int      `json:"blanks,omitempty"`
+		Raw     *blockRaw `json:"raw,omitempty"`
+	}
+
+	blockRaw struct {
+		Above  []string `json:"above,omitempty"`
+		Header string   `json:"header,omitempty"`
+		Sum    string   `json:"sum,omitempty"`
+	}
+)
+
+func (s shadowJSON) MarshalJSON() ([]byte, error) {
+	if s.inline == "" {
+		return json.Marshal(s.value)
+	}
+	return json.Marshal(struct {
+		Value  string `json:"value"`
+		Inline string `json:"inline"`
+	}{s.value, s.inline})
+}
+
+func (s *shadowJSON) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*s = shadowJSON{}
+		return json.Unmarshal(data, &s.value)
+	}
+	var obj struct {
+		Value  string `json:"value"`
+		Inline string `json:"inline"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	s.value, s.inline = obj.Value, obj.Inline
+	return nil
+}
+
+const (
+	itemRow   = "row"
+	itemBlock = "block"
+)
+
+func (it itemJSON) MarshalJSON() ([]byte, error) {
+	if it.block != nil {
+		return json.Marshal(struct {
+			Type string `json:"type"`
+			*blockJSON
+		}{itemBlock, it.block})
+	}
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*rowJSON
+	}{itemRow, it.row})
+}
+
+func (it *itemJSON) UnmarshalJSON(data []byte) error {
+	var head struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return err
+	}
+	switch head.Type {
+	case itemRow:
+		it.row = &rowJSON{}
+		return json.Unmarshal(data, it.row)
+	case itemBlock:
+		it.block = &blockJSON{}
+		return json.Unmarshal(data, it.block)
+	default:
+		return fmt.Errorf("item type %q: want %q or %q", head.Type, itemRow, itemBlock)
+	}
+}
+
+// MarshalDocument returns the whole document as JSON: every item in order,
+// blocks with their header comments, rows with their comments, inline
+// comments, shadows and commented-out state, and the lines exactly as they were
+// read. [Env.UnmarshalDocument] turns it back into a document that writes the
+// same bytes, which is what lets a tool in another language — a web editor, a
+// deployment dashboard — change a .env file without reformatting it.
+//
+// The schema, at [DocumentVersion] 1:
+//
+//	{
+//	  "version": 1,
+//	  "eol": "\r\n",
+//	  "items": [
+//	    {"type": "row", "key": "NAME", "value": "app",
+//	     "raw": {"line": "NAME=app", "sum": "..."}},
+//	    {"type": "block", "prefix": "DB", "comment": "Database", "blanks": 1,
+//	     "raw": {"header": "### Database ###", "sum": "..."},
+//	     "rows": [
+//	       {"key": "DB_HOST", "value": "db", "comment": "primary",
+//	        "inline": "or a replica",
+//	        "shadows": ["localhost", {"value": "db2", "inline": "the old one"}],
+//	        "raw": {"above": ["# primary", "# DB_HOST=localhost",
+//	                          "# DB_HOST=db2 # the old one"],
+//	                "line": "DB_HOST=db # or a replica", "sum": "..."}}
+//	     ]}
+//	  ],
+//	  "trailer": ["# end"]
+//	}
+//
+// eol is absent for a document built in memory, which is written with "\n";
+// blanks, the blank lines after a block, is absent for one whose spacing is
+// left to the encoder. A shadow is its value, or an object when a comment
+// trails it. raw is absent for whatever has no recorded lines; below, for a
+// commented row, holds the lines stating its shadows, which are written under
+// it. The sum is opaque: a checksum of the fields the lines state. Empty
+// strings, lists and false are left out throughout.
+//
+// JSON carries only valid UTF-8, so a document holding anything else is an
+// error rather than being quietly altered.
+func (e *Env) MarshalDocument() ([]byte, error) {
+	// encoding/json replaces invalid UTF-8 with U+FFFD without a word, so the
+	// model is checked first.
+	if err := e.checkUTF8(); err != nil {
+		return nil, err
+	}
+	doc := docJSON{Version: DocumentVersion, EOL: e.eol, Items: make([]itemJSON, 0, len(e.items)), Trailer: e.trailer}
+	for _, it := range e.items {
+		switch v := it.(type) {
+		case *Row:
+			doc.Items = append(doc.Items, itemJSON{row: rowToJSON(v)})
+		case *Block:
+			b := &blockJSON{Prefix: v.prefix, Comment: v.comment, Rows: make([]rowJSON, 0, len(v.rows))}
+			if v.blanksAfter >= 0 {
+				b.Blanks = &v.blanksAfter
+			}
+			if v.rawHeader != "" || len(v.rawPrefix) > 0 {
+				b.Raw = &blockRaw{Above: v.rawPrefix, Header: v.rawHeader, Sum: blockSum(v.prefix, v.comment)}
+			}
+			for _, r := range v.rows {
+				b.Rows = append(b.Rows, *rowToJSON(r))
+			}
+			doc.Items = append(doc.Items, itemJSON{block: b})
+		}
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("envi: document: %w", err)
+	}
+	return data, nil
+}
+
+// rowToJSON describes r in the document schema.
+func rowToJSON(r *Row) *rowJSON {
+	j := &rowJSON{
+		Key:       r.key,
+		Value:     r.value,
+		Comment:   r.comment,
+		Inline:    r.inline,
+		Shadows:   shadowsToJSON(r.shadows),
+		Commented: r.commented,
+	}
+	if r.parsed || len(r.rawPrefix) > 0 {
+		j.Raw = &rowRaw{Above: r.rawPrefix, Sum: rowSum(r)}
+		if r.parsed {
+			// A canonical assignment is not stored, since rendering it gives the
+			// same bytes; a reader of the document should not have to know how.
+			j.Raw.Line = r.rawLine
+			if j.Raw.Line == "" {
+				j.Raw.Line = renderLine(r)
+			}
+			j.Raw.Below = r.rawBelow
+		}
+	}
+	return j
+}
+
+// renderLine renders r's assignment line, without its terminator.
+func renderLine(r *Row) string {
+	var b strings.Builder
+	bw := bufio.NewWriter(&b)
+	enc := &Encoder{cfg: newConfig(nil)}
+	enc.writeAssignmentLine(bw, r)
+	_ = bw.Flush() // writing to a strings.Builder cannot fail
+	return b.String()
+}
+
+// checkUTF8 reports the first piece of e that is not valid UTF-8.
+func (e *Env) checkUTF8() error {
+	valid := func(ss ...string) bool {
+		for _, s := range ss {
+			if !utf8.ValidString(s) {
+				return false
+			}
+		}
+		return true
+	}
+	for it := range e.Items() {
+		if b, ok := it.(*Block); ok {
+			if !valid(b.comment, b.rawHeader) || !valid(b.rawPrefix...) {
+				return fmt.Errorf("envi: document: block %s is not valid UTF-8", b.prefix)
+			}
+		}
+	}
+	for r := range e.Rows() {
+		if !valid(r.value, r.comment, r.inline, r.rawLine) || slices.ContainsFunc(r.shadows, func(s shadow) bool { return !valid(s.value, s.inline) }) || !valid(r.rawPrefix...) || !valid(r.rawBelow...) {
+			return fmt.Errorf("envi: document: %s is not valid UTF-8", r.key)
+		}
+	}
+	if !valid(e.trailer...) {
+		return errors.New("envi: document: the trailer is not valid UTF-8")
+	}
+	return nil
+}
+
+// UnmarshalDocument replaces the contents of e with the document data
+// describes, in the schema [Env.MarshalDocument] writes. A document read back
+// unchanged writes exactly the bytes it was made from.
+//
+// The recorded lines are checked against the fields before they are trusted,
+// the way the setters of [Row] and [Block] drop them. A row whose value, inline
+// comment or commented-out state was edited without touching raw is written
+// from the model, the lines above it kept; one whose comment or shadows changed
+// loses those lines as well, and a block whose comment changed its header line.
+// An editor therefore only has to change the fields it means to change. Rows
+// and blocks it adds need no raw at all, and are laid out as the encoder lays
+// out a document built in memory. Recorded lines without a sum are taken as
+// they are.
+//
+// A key stated twice, a row in a block not of its prefix, a recorded line
+// holding a line break and a version other than [DocumentVersion] are errors,
+// which leave e as it was.
+func (e *Env) UnmarshalDocument(data []byte) error {
+	var doc docJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("envi: document: %w", err)
+	}
+	if doc.Version != DocumentVersion {
+		return fmt.Errorf("envi: document: version %d, want %d", doc.Version, DocumentVersion)
+	}
+	if doc.EOL != "" && doc.EOL != "\n" && doc.EOL != "\r\n" {
+		return fmt.Errorf("envi: document: eol %q: want \"\\n\" or \"\\r\\n\"", doc.EOL)
+	}
+	if err := checkLines("the trailer", doc.Trailer); err != nil {
+		return err
+	}
+
+	out := &Env{eol: doc.EOL, trailer: doc.Trailer}
+	seen := make(map[string]bool)
+	for _, it := range doc.Items {
+		switch {
+		case it.row != nil:
+			r, err := rowFromJSON(it.row, seen)
+			if err != nil {
+				return err
+			}
+			out.items = append(out.items, r)
+		case it.block != nil:
+			b, err := blockFromJSON(it.block, seen)
+			if err != nil {
+				return err
+			}
+			out.items = append(out.items, b)
+		}
+	}
+	out.reindex()
+	*e = *out
+	return nil
+}
+
+// blockFromJSON builds a block from its description.
+func blockFromJSON(j *blockJSON, seen map[string]bool) (*Block, error) {
+	b := NewBlock(j.Prefix)
+	if b.prefix == "" {
+		return nil, errors.New("envi: document: a block has no prefix")
+	}
+	b.comment = j.Comment
+	if j.Blanks != nil {
+		if *j.Blanks < 0 {
+			return nil, fmt.Errorf("envi: document: block %s: blanks %d", b.prefix, *j.Blanks)
+		}
+		b.blanksAfter = *j.Blanks
+	}
+	if j.Raw != nil {
+		if err := checkLines("block "+b.prefix, append([]string{j.Raw.Header}, j.Raw.Above...)); err != nil {
+			return nil, err
+		}
+		b.rawHeader = j.Raw.Header
+		b.rawPrefix = j.Raw.Above
+		if j.Raw.Sum != "" && j.Raw.Sum != blockSum(b.prefix, b.comment) {
+			b.rawHeader = ""
+		}
+	}
+	for i := range j.Rows {
+		r, err := rowFromJSON(&j.Rows[i], seen)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.Add(r); err != nil {
+			return nil, fmt.Errorf("envi: document: %w", err)
+		}
+	}
+	return b, nil
+}
+
+// shadowsToJSON describes a row's shadows in the document schema.
+func shadowsToJSON(shadows []shadow) []shadowJSON {
+	out := make([]shadowJSON, len(shadows))
+	for i, s := range shadows {
+		out[i] = shadowJSON(s)
+	}
+	return out
+}
+
+// rowFromJSON builds a row from its description, noting its key in seen.
+func rowFromJSON(j *rowJSON, seen map[string]bool) (*Row, error) {
+	r := &Row{
+		key:       NormalizeKey(j.Key),
+		value:     j.Value,
+		comment:   j.Comment,
+		inline:    j.Inline,
+		commented: j.Commented,
+	}
+	for _, s := range j.Shadows {
+		r.addParsedShadow(shadow(s))
+	}
+	if r.key == "" {
+		return nil, fmt.Errorf("envi: document: key %q makes no key", j.Key)
+	}
+	if seen[r.key] {
+		return nil, fmt.Errorf("envi: document: %s is stated twice", r.key)
+	}
+	seen[r.key] = true
+	if j.Raw != nil {
+		if err := checkLines(r.key, slices.Concat([]string{j.Raw.Line}, j.Raw.Above, j.Raw.Below)); err != nil {
+			return nil, err
+		}
+		r.rawPrefix = j.Raw.Above
+		r.rawLine = j.Raw.Line
+		r.parsed = j.Raw.Line != ""
+		if r.parsed && r.commented {
+			r.rawBelow = j.Raw.Below
+		}
+		if j.Raw.Sum != "" {
+			sum := rowSum(r)
+			if sum[:sumLen] != j.Raw.Sum[:min(sumLen, len(j.Raw.Sum))] {
+				r.dropLine()
+			}
+			if sum[sumLen:] != j.Raw.Sum[min(sumLen, len(j.Raw.Sum)):] {
+				r.dropRaw()
+			}
+		}
+	}
+	return r, nil
+}
+
+// checkLines rejects recorded lines that are more than one line each.
+func checkLines(what string, lines []string) error {
+	for _, l := range lines {
+		if strings.Contains(l, "\n") {
+			return fmt.Errorf("envi: document: %s: a recorded line holds a line break", what)
+		}
+	}
+	return nil
+}
+
+// sumLen is the length of each half of a row's sum.
+const sumLen = 8
+
+// rowSum returns the checksum recorded with a row's lines. Its first half
+// covers what the assignment line states, its second what the lines above it
+// state, so that an edit drops only the lines it makes stale. The commented-out
+// state counts above as well when the row has shadows, which are written on
+// the other side of a commented row — as [Row.SetCommented] has it.
+func rowSum(r *Row) string {
+	line := fnv.New32a()
+	writeFields(line, r.key, r.value, r.inline, strconv.FormatBool(r.commented))
+	above := fnv.New32a()
+	writeFields(above, r.key, r.comment)
+	for _, s := range r.shadows {
+		writeFields(above, s.value, s.inline)
+	}
+	if len(r.shadows) > 0 {
+		writeFields(above, strconv.FormatBool(r.commented))
+	}
+	return fmt.Sprintf("%0*x%0*x", sumLen, line.Sum32(), sumLen, above.Sum32())
+}
+
+// blockSum returns the checksum recorded with a block's header line.
+func blockSum(prefix, comment string) string {
+	h := fnv.New32a()
+	writeFields(h, prefix, comment)
+	return fmt.Sprintf("%0*x", sumLen, h.Sum32())
+}
+
+// writeFields feeds fields to h, each terminated so that moving text from one
+// to the next changes the sum.
+func writeFields(h hash.Hash32, fields ...string) {
+	for _, f := range fields {
+		_, _ = io.WriteString(h, f) // a hash never fails to write
+		_, _ = h.Write([]byte{0})
+	}
+}
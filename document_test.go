@@ -0,0 +1,213 @@
+package envi_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+const documentSrc = "# app settings\nNAME=app # the name\n\n###   ---[ Database ]---   ###\n# primary\n# DB_HOST=localhost\nDB_HOST = \"db\"\n# DB_PORT=5432\n\n\n# the end\n"
+
+// editDocument applies edit to the JSON form of src and returns what the
+// document read back from it writes.
+func editDocument(t *testing.T, src string, edit func(doc map[string]any)) string {
+	t.Helper()
+	e, err := envi.ParseString(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := e.MarshalDocument()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	edit(doc)
+	if data, err = json.Marshal(doc); err != nil {
+		t.Fatal(err)
+	}
+	var back envi.Env
+	if err := back.UnmarshalDocument(data); err != nil {
+		t.Fatal(err)
+	}
+	return back.String()
+}
+
+// docItem returns item i of a document in its JSON form.
+func docItem(doc map[string]any, i int) map[string]any {
+	return doc["items"].([]any)[i].(map[string]any)
+}
+
+func TestDocumentRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, src := range []string{
+		documentSrc,
+		readmeExample,
+		"",
+		"A=1\r\n\r\n# c\r\nB_X='2'\r\n# B_Y=3\r\n",
+		"K=1\nK=2\n# K=3\n",
+		"export K=v\nL: 'w'\n",
+		"# K=a # @profile=x\nK=b # live\n",
+		"# K=1 # one\n\n#K=2\nL=3\n",
+	} {
+		e, err := envi.ParseString(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The document promises what the Env writes, which is the input
+		// itself unless the input states a key twice.
+		if want, got := e.String(), editDocument(t, src, func(map[string]any) {}); got != want {
+			t.Errorf("round trip of %q gave %q, want %q", src, got, want)
+		}
+	}
+}
+
+func TestDocumentSchema(t *testing.T) {
+	t.Parallel()
+
+	e, err := envi.ParseString(documentSrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := e.MarshalDocument()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Version int    `json:"version"`
+		EOL     string `json:"eol"`
+		Items   []struct {
+			Type    string `json:"type"`
+			Key     string `json:"key"`
+			Inline  string `json:"inline"`
+			Prefix  string `json:"prefix"`
+			Comment string `json:"comment"`
+			Rows    []struct {
+				Key       string   `json:"key"`
+				Value     string   `json:"value"`
+				Shadows   []string `json:"shadows"`
+				Commented bool     `json:"commented"`
+				Raw       struct {
+					Line string `json:"line"`
+				} `json:"raw"`
+			} `json:"rows"`
+		} `json:"items"`
+		Trailer []string `json:"trailer"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Version != envi.DocumentVersion || doc.EOL != "\n" || len(doc.Items) != 2 {
+		t.Fatalf("document = %s", data)
+	}
+	if it := doc.Items[0]; it.Type != "row" || it.Key != "NAME" || it.Inline != "the name" {
+		t.Errorf("first item = %+v", it)
+	}
+	blk := doc.Items[1]
+	if blk.Type != "block" || blk.Prefix != "DB" || blk.Comment != "Database" || len(blk.Rows) != 2 {
+		t.Fatalf("second item = %+v", blk)
+	}
+	if r := blk.Rows[0]; r.Value != "db" || len(r.Shadows) != 1 || r.Shadows[0] != "localhost" || r.Raw.Line != `DB_HOST = "db"` {
+		t.Errorf("DB_HOST = %+v", r)
+	}
+	if r := blk.Rows[1]; !r.Commented || r.Raw.Line != "# DB_PORT=5432" {
+		t.Errorf("DB_PORT = %+v", r)
+	}
+	if strings.Join(doc.Trailer, "|") != "||# the end" {
+		t.Errorf("trailer = %q", doc.Trailer)
+	}
+}
+
+func TestDocumentEdits(t *testing.T) {
+	t.Parallel()
+
+	dbHost := func(doc map[string]any) map[string]any {
+		return docItem(doc, 1)["rows"].([]any)[0].(map[string]any)
+	}
+
+	tests := []struct {
+		name string
+		edit func(doc map[string]any)
+		want string
+	}{
+		{
+			// Only the assignment is rewritten; the lines above it stand.
+			name: "value",
+			edit: func(doc map[string]any) { dbHost(doc)["value"] = "db2" },
+			want: strings.Replace(documentSrc, `DB_HOST = "db"`, "DB_HOST=db2", 1),
+		},
+		{
+			name: "commented",
+			edit: func(doc map[string]any) { docItem(doc, 0)["commented"] = true },
+			want: strings.Replace(documentSrc, "NAME=app", "# NAME=app", 1),
+		},
+		{
+			// As with Row.SetComment, the whole row is written afresh.
+			name: "comment",
+			edit: func(doc map[string]any) { dbHost(doc)["comment"] = "replica" },
+			want: strings.Replace(documentSrc, "# primary\n# DB_HOST=localhost\nDB_HOST = \"db\"", "# replica\n# DB_HOST=localhost\nDB_HOST=db", 1),
+		},
+		{
+			name: "shadow comment",
+			edit: func(doc map[string]any) {
+				dbHost(doc)["shadows"] = []any{map[string]any{"value": "localhost", "inline": "@profile=dev"}}
+			},
+			want: strings.Replace(documentSrc, "# DB_HOST=localhost\nDB_HOST = \"db\"", "# DB_HOST=localhost # @profile=dev\nDB_HOST=db", 1),
+		},
+		{
+			name: "header",
+			edit: func(doc map[string]any) { docItem(doc, 1)["comment"] = "Storage" },
+			want: strings.Replace(documentSrc, "###   ---[ Database ]---   ###", "###   ---[ Storage ]---   ###", 1),
+		},
+		{
+			name: "added row",
+			edit: func(doc map[string]any) {
+				blk := docItem(doc, 1)
+				blk["rows"] = append(blk["rows"].([]any), map[string]any{"key": "DB_USER", "value": "app"})
+			},
+			want: strings.Replace(documentSrc, "# DB_PORT=5432\n", "# DB_PORT=5432\nDB_USER=app\n", 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := editDocument(t, documentSrc, tt.edit); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentErrors(t *testing.T) {
+	t.Parallel()
+
+	for name, data := range map[string]string{
+		"version":   `{"version": 2, "items": []}`,
+		"no type":   `{"version": 1, "items": [{"key": "A"}]}`,
+		"duplicate": `{"version": 1, "items": [{"type": "row", "key": "A"}, {"type": "row", "key": "a"}]}`,
+		"prefix":    `{"version": 1, "items": [{"type": "block", "prefix": "DB", "rows": [{"key": "APP_X"}]}]}`,
+		"no key":    `{"version": 1, "items": [{"type": "row", "key": ""}]}`,
+		"eol":       `{"version": 1, "eol": "\r", "items": []}`,
+		"break":     `{"version": 1, "items": [{"type": "row", "key": "A", "raw": {"line": "A=1\nB=2"}}]}`,
+		"malformed": `{"version": 1, "items": [}`,
+	} {
+		e := envi.New(envi.NewRow("KEEP", "1"))
+		if err := e.UnmarshalDocument([]byte(data)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+		if !e.Has("KEEP") {
+			t.Errorf("%s: a failed read changed the document", name)
+		}
+	}
+
+	e := envi.New(envi.NewRow("K", "\xff"))
+	if _, err := e.MarshalDocument(); err == nil || !strings.Contains(err.Error(), "K is not valid UTF-8") {
+		t.Errorf("invalid UTF-8: err = %v", err)
+	}
+}
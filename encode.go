@@ -39,6 +39,9 @@ func (enc *Encoder) Encode(e *Env) error {
 	if e == nil {
 		return nil
 	}
+	if enc.cfg.redaction != nil {
+		e = e.RedactedView(enc.cfg.redaction)
+	}
 	enc.eol = e.eol
 	if enc.eol == "" {
 		enc.eol = "\n"
@@ -71,6 +74,10 @@ func (enc *Encoder) encode(bw *bufio.Writer, e *Env) {
 	if enc.canReproduce() {
 		enc.writeRawLines(bw, e.trailer)
 	}
+	if enc.cfg.signKey != nil {
+		bw.WriteString(e.signature(enc.cfg.signKey))
+		bw.WriteString(enc.eol)
+	}
 }
 
 // ordered returns the items to write, sorted into a copy when the configuration
@@ -175,8 +182,14 @@ func (enc *Encoder) canReproduce() bool {
 		enc.cfg.order == OrderSource
 }
 
+// writeRawLines writes recorded lines as they were. When signing, it leaves out
+// every integrity trailer among them: one that rows were appended after is no
+// longer the last line, and would linger as a stale comment otherwise.
 func (enc *Encoder) writeRawLines(bw *bufio.Writer, lines []string) {
 	for _, l := range lines {
+		if enc.cfg.signKey != nil && isSignature(l) {
+			continue
+		}
 		bw.WriteString(l)
 		bw.WriteString(enc.eol)
 	}
@@ -209,11 +222,15 @@ func (enc *Encoder) writeRow(bw *bufio.Writer, r *Row) bool {
 		} else {
 			enc.writeAssignmentLine(bw, r)
 		}
+		enc.writeRawLines(bw, r.rawBelow)
 		return true
 	}
 
 	if r.comment != "" && enc.cfg.comments && !above {
 		for line := range strings.SplitSeq(r.comment, "\n") {
+			if enc.cfg.signKey != nil && isSignature("# "+line) {
+				continue
+			}
 			bw.WriteString("# ")
 			bw.WriteString(line)
 			bw.WriteString(enc.eol)
@@ -226,7 +243,9 @@ func (enc *Encoder) writeRow(bw *bufio.Writer, r *Row) bool {
 	// nothing absorbs shadows upwards into an inert row — so its shadows are
 	// written below. Writing them on the wrong side would reorder the file;
 	// writing none at all, as this once did for a commented row, deleted them.
-	if enc.cfg.shadows && !r.commented {
+	// The lines recorded above a live row hold its shadows already: anything
+	// that changes them drops those lines.
+	if enc.cfg.shadows && !r.commented && !above {
 		enc.writeShadows(bw, r)
 	}
 
@@ -242,7 +261,7 @@ func (enc *Encoder) writeRow(bw *bufio.Writer, r *Row) bool {
 func (enc *Encoder) writeShadows(bw *bufio.Writer, r *Row) {
 	for _, s := range r.shadows {
 		bw.WriteString("# ")
-		enc.writeAssignment(bw, r.key, s)
+		enc.writeAssignment(bw, r.key, s.value, s.inline)
 	}
 }
 
@@ -263,14 +282,18 @@ func (enc *Encoder) writeAssignmentLine(bw *bufio.Writer, r *Row) {
 	bw.WriteString(enc.eol)
 }
 
-// writeAssignment writes KEY=value for a raw string value, used for shadows,
-// which carry no recorded rendering of their own.
-func (enc *Encoder) writeAssignment(bw *bufio.Writer, key, value string) {
+// writeAssignment writes KEY=value for a raw string value, and the comment
+// trailing it, used for shadows, which carry no recorded rendering of their own.
+func (enc *Encoder) writeAssignment(bw *bufio.Writer, key, value, inline string) {
 	enc.buf = enc.buf[:0]
 	enc.buf = appendMinimal(enc.buf, value)
 	bw.WriteString(key)
 	bw.WriteByte('=')
 	bw.Write(enc.buf)
+	if inline != "" && enc.cfg.comments {
+		bw.WriteString(" # ")
+		bw.WriteString(inline)
+	}
 	bw.WriteString(enc.eol)
 }
 
@@ -445,3 +445,60 @@ func (e *Env) Export(override bool) error {
 	}
 	return nil
 }
+
+// Environ returns base, a list of "KEY=value" strings such as [os.Environ]
+// returns, with the document's configured rows added: the environment for an
+// [os/exec.Cmd] that should see the file without this process seeing it too.
+//
+//	cmd := exec.Command("worker")
+//	cmd.Env = env.Environ(os.Environ(), false)
+//
+// With override false a variable base already sets keeps its value, as
+// [Env.Export] leaves an existing variable alone; with override true the
+// document's value replaces every entry of that name. Entries of base keep
+// their order and the document's rows follow, in document order. base itself
+// is not modified, and a nil base yields the document's rows alone.
+func (e *Env) Environ(base []string, override bool) []string {
+	rows := make(map[string]string, e.Len())
+	var order []string
+	for r := range e.Rows() {
+		if r.commented {
+			continue
+		}
+		if _, dup := rows[r.key]; !dup {
+			order = append(order, r.key)
+		}
+		rows[r.key] = r.value
+	}
+
+	out := make([]string, 0, len(base)+len(order))
+	set := make(map[string]bool, len(base))
+	for _, kv := range base {
+		name := environName(kv)
+		set[name] = true
+		if _, ours := rows[name]; ours && override {
+			continue
+		}
+		out = append(out, kv)
+	}
+	for _, key := range order {
+		if set[key] && !override {
+			continue
+		}
+		out = append(out, key+"="+rows[key])
+	}
+	return out
+}
+
+// environName returns the name of a "KEY=value" entry. Windows keeps entries
+// such as "=C:=C:\dir" whose name starts with the separator, so the search
+// begins past the first byte.
+func environName(kv string) string {
+	if kv == "" {
+		return ""
+	}
+	if i := strings.IndexByte(kv[1:], '='); i >= 0 {
+		return kv[:i+1]
+	}
+	return kv
+}
@@ -265,6 +265,165 @@ func TestSetValueDropsRecordedRendering(t *testing.T) {
 	}
 }
 
+func TestSetCommentedKeepsTheLinesAbove(t *testing.T) {
+	t.Parallel()
+
+	e := mustParse(t, "A=1\n\n# about B\nB=2\n")
+	e.Get("B").SetCommented(true)
+	if got, want := e.String(), "A=1\n\n# about B\n# B=2\n"; got != want {
+		t.Errorf("commented out = %q, want %q", got, want)
+	}
+	e.Get("B").SetCommented(false)
+	if got, want := e.String(), "A=1\n\n# about B\nB=2\n"; got != want {
+		t.Errorf("restored = %q, want %q", got, want)
+	}
+}
+
+func TestPromoteIsATwoLineChange(t *testing.T) {
+	t.Parallel()
+
+	const src = "A=1\n\n# the database\n#DB_URL='postgres://staging'\nDB_URL=\"postgres://prod\" # live\n\nC=3\n"
+	e := mustParse(t, src)
+	r := e.Get("DB_URL")
+	if r.Promote("postgres://nowhere") {
+		t.Fatal("Promote accepted a value that is not a shadow")
+	}
+	if !r.Promote("postgres://staging") {
+		t.Fatal("Promote refused a shadow")
+	}
+	want := "A=1\n\n# the database\n# DB_URL=postgres://prod\nDB_URL=postgres://staging # live\n\nC=3\n"
+	if got := e.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := slices.Collect(r.Shadows()); !slices.Equal(got, []string{"postgres://prod"}) {
+		t.Errorf("shadows = %v", got)
+	}
+
+	// The result reads back as the same row.
+	back := mustParse(t, e.String()).Get("DB_URL")
+	if back.Value() != "postgres://staging" || !slices.Equal(slices.Collect(back.Shadows()), slices.Collect(r.Shadows())) {
+		t.Errorf("read back as %q with shadows %v", back.Value(), slices.Collect(back.Shadows()))
+	}
+
+	// A row built in memory is written from the model.
+	m := envi.NewRow("K", "v").AddShadow("a").AddShadow("b")
+	m.Promote("b")
+	if got, want := envi.New(m).String(), "# K=a\n# K=v\nK=b\n"; got != want {
+		t.Errorf("in memory: got %q, want %q", got, want)
+	}
+}
+
+func TestPromoteCarriesTrailingComments(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name, src, promote, want string
+	}{
+		{
+			name:    "both commented",
+			src:     "# K=a # replica\nK=b # live\n",
+			promote: "a",
+			want:    "# K=b # live\nK=a # replica\n",
+		},
+		{
+			name:    "shadow commented",
+			src:     "# K=a # replica\nK=b\n",
+			promote: "a",
+			want:    "# K=b\nK=a # replica\n",
+		},
+		{
+			name:    "profile label on the assignment",
+			src:     "# K=a\nK=b # @profile=prod\n",
+			promote: "a",
+			want:    "# K=b # @profile=prod\nK=a\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := mustParse(t, tc.src)
+			if !e.Get("K").Promote(tc.promote) {
+				t.Fatal("Promote refused a shadow")
+			}
+			if got := e.String(); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRotateCyclesThroughShadows(t *testing.T) {
+	t.Parallel()
+
+	e := mustParse(t, "# note\n# K=a\n# K=b\nK=c\n")
+	r := e.Get("K")
+	for _, want := range []string{
+		"# note\n# K=b\n# K=c\nK=a\n",
+		"# note\n# K=c\n# K=a\nK=b\n",
+		"# note\n# K=a\n# K=b\nK=c\n",
+	} {
+		if !r.Rotate() {
+			t.Fatal("Rotate refused a row with shadows")
+		}
+		if got := e.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+
+	if envi.NewRow("K", "v").Rotate() {
+		t.Error("Rotate reported a change for a row without shadows")
+	}
+}
+
+func TestShadowList(t *testing.T) {
+	t.Parallel()
+
+	e := mustParse(t, "# the database\n#DB='old'  # used before the migration\n# DB=next # @profile=staging\nDB=now\n")
+	want := []envi.Shadow{
+		{Value: "old", Comment: "used before the migration", Raw: "#DB='old'  # used before the migration"},
+		{Value: "next", Comment: "@profile=staging", Label: "staging", Raw: "# DB=next # @profile=staging"},
+	}
+	if got := e.Get("DB").ShadowList(); !slices.Equal(got, want) {
+		t.Errorf("ShadowList = %+v, want %+v", got, want)
+	}
+
+	m := envi.NewRow("K", "v").AddShadowWithComment("a", "why not")
+	if got, want := m.ShadowList(), []envi.Shadow{{Value: "a", Comment: "why not"}}; !slices.Equal(got, want) {
+		t.Errorf("in memory: ShadowList = %+v, want %+v", got, want)
+	}
+	if got, want := envi.New(m).String(), "# K=a # why not\nK=v\n"; got != want {
+		t.Errorf("in memory: got %q, want %q", got, want)
+	}
+}
+
+func TestAddAndRemoveShadowKeepTheLines(t *testing.T) {
+	t.Parallel()
+
+	const src = "A=1\n\n# about b\n#B='x'  # first\nB = 2 # live\n\nC=3\n"
+	e := mustParse(t, src)
+	r := e.Get("B")
+	r.AddShadowWithComment("y", "second").AddShadow("x")
+	want := "A=1\n\n# about b\n#B='x'  # first\n# B=y # second\nB = 2 # live\n\nC=3\n"
+	if got := e.String(); got != want {
+		t.Errorf("added: got %q, want %q", got, want)
+	}
+
+	if !r.RemoveShadow("x") || r.RemoveShadow("zzz") {
+		t.Error("RemoveShadow misreported what it removed")
+	}
+	want = "A=1\n\n# about b\n# B=y # second\nB = 2 # live\n\nC=3\n"
+	if got := e.String(); got != want {
+		t.Errorf("removed: got %q, want %q", got, want)
+	}
+
+	// A commented row's shadows are written below it.
+	e = mustParse(t, "# about k\n# K=1\n")
+	e.Get("K").AddShadowWithComment("2", "note")
+	if got, want := e.String(), "# about k\n# K=1\n# K=2 # note\n"; got != want {
+		t.Errorf("commented: got %q, want %q", got, want)
+	}
+}
+
 func TestMergeCopiesInsteadOfSharing(t *testing.T) {
 	t.Parallel()
 
@@ -341,6 +500,31 @@ func TestExport(t *testing.T) {
 	})
 }
 
+func TestEnviron(t *testing.T) {
+	t.Parallel()
+
+	e := envi.New(
+		envi.NewRow("A", "file"),
+		envi.NewRow("B", "file"),
+		envi.NewRow("C", "x").SetCommented(true),
+	)
+	base := []string{"=C:=C:\\dir", "A=base", "PATH=/bin", "A=again"}
+	kept := slices.Clone(base)
+
+	if got, want := e.Environ(base, false), []string{"=C:=C:\\dir", "A=base", "PATH=/bin", "A=again", "B=file"}; !slices.Equal(got, want) {
+		t.Errorf("without override = %q, want %q", got, want)
+	}
+	if got, want := e.Environ(base, true), []string{"=C:=C:\\dir", "PATH=/bin", "A=file", "B=file"}; !slices.Equal(got, want) {
+		t.Errorf("with override = %q, want %q", got, want)
+	}
+	if got, want := e.Environ(nil, false), []string{"A=file", "B=file"}; !slices.Equal(got, want) {
+		t.Errorf("nil base = %q, want %q", got, want)
+	}
+	if !slices.Equal(base, kept) {
+		t.Errorf("base was modified: %q", base)
+	}
+}
+
 func TestAddIgnoresNilItems(t *testing.T) {
 	t.Parallel()
 
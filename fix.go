@@ -0,0 +1,158 @@
+package envi
+
+import (
+	"cmp"
+	"slices"
+)
+
+// A FixOp names the edit a [Fix] makes to the document model.
+type FixOp string
+
+const (
+	// FixRewrite writes the row's assignment afresh, the way the encoder
+	// renders it: the key in canonical form and the value quoted if it needs
+	// to be. The lines around it are left as they are.
+	FixRewrite FixOp = "rewrite"
+
+	// FixQuote writes the row's assignment afresh with the value in double
+	// quotes, which FixRewrite leaves off a value that reads back the same
+	// without them, such as one holding a space.
+	FixQuote FixOp = "quote"
+
+	// FixDropValue removes the earlier definition of a duplicate key, which
+	// the later one displaced, from the row the two fold into.
+	FixDropValue FixOp = "drop-value"
+)
+
+// rank orders the edits of one line: the lower goes first.
+func (op FixOp) rank() int {
+	switch op {
+	case FixDropValue:
+		return 0
+	case FixQuote:
+		return 1
+	}
+	return 2
+}
+
+// covers reports whether making the edit op also makes other: quoting writes
+// the key in canonical form as well.
+func (op FixOp) covers(other FixOp) bool {
+	return op == other || (op == FixQuote && other == FixRewrite)
+}
+
+// A Fix is an edit that resolves a [Problem], stated as an operation on the
+// document [Check] returned alongside the report, so that applying it with
+// [Env.ApplyFixes] changes only what it has to: the line it names.
+type Fix struct {
+	// Op is the edit to make, to the row under Key.
+	Op  FixOp  `json:"op"`
+	Key string `json:"key"`
+
+	// Line is the source line the fix rewrites or removes. Two fixes of one
+	// line overlap, and only one of them is applied.
+	Line int `json:"line"`
+
+	// Msg describes the fix in lower case: "write the key as APP_NAME".
+	Msg string `json:"message"`
+}
+
+// IsZero reports whether f is no fix at all, as in a [Problem] without one.
+func (f Fix) IsZero() bool { return f.Op == "" }
+
+// Fixes returns the fixes the findings carry, in the order of the findings.
+// Not every finding has one: nothing can say what an empty value should have
+// been.
+func (r *Report) Fixes() []Fix {
+	var fixes []Fix
+	for _, p := range r.problems {
+		if !p.Fix.IsZero() {
+			fixes = append(fixes, p.Fix)
+		}
+	}
+	return fixes
+}
+
+// ApplyFixes applies fixes to the document, in line order, and returns those
+// it applied. A fix of a line an earlier one has already changed is skipped —
+// a second run of the check offers it again — unless the first has made that
+// edit as well. So is a fix whose row is gone or no longer live.
+//
+// Each edit is one [Row] operation, so a file written back after it differs
+// only in the lines fixed:
+//
+//	env, rep, err := envi.CheckFile(".env")
+//	applied := env.ApplyFixes(rep.Fixes()...)
+//	err = envi.Save(env, ".env")
+func (e *Env) ApplyFixes(fixes ...Fix) []Fix {
+	// Of two fixes of one line, removing it goes first: it makes any other
+	// edit of the line moot.
+	todo := slices.Clone(fixes)
+	slices.SortStableFunc(todo, func(a, b Fix) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Op.rank(), b.Op.rank()))
+	})
+
+	var applied []Fix
+	done := make(map[int]Fix)
+	for _, f := range todo {
+		if prev, ok := done[f.Line]; ok {
+			if prev.Key == f.Key && prev.Op.covers(f.Op) {
+				applied = append(applied, f)
+			}
+			continue
+		}
+		r := e.Get(f.Key)
+		if r == nil || r.commented {
+			continue
+		}
+		switch f.Op {
+		case FixRewrite:
+			r.dropLine()
+		case FixQuote:
+			if !r.quoteLine() {
+				continue
+			}
+		case FixDropValue:
+			if !r.dropDefinition(f.Line) {
+				continue
+			}
+		default:
+			continue
+		}
+		done[f.Line] = f
+		applied = append(applied, f)
+	}
+	return applied
+}
+
+// quoteLine records the row's assignment with its value double-quoted, as the
+// line to write in its place. It reports false for a row already written from
+// the model, which has no such line to record.
+func (r *Row) quoteLine() bool {
+	if !r.parsed {
+		return false
+	}
+	line := r.key + "=" + string(appendQuoted(nil, r.value))
+	if r.inline != "" {
+		line += " # " + r.inline
+	}
+	r.dropLine()
+	r.rawLine, r.parsed = line, true
+	return true
+}
+
+// dropDefinition removes the statement on line that a later one of the same
+// key overrode: its place in [Row.Overrode], and the shadow it was demoted to.
+// A shadow the file also states as one, commented out, stays. It reports false
+// when the row records no such statement.
+func (r *Row) dropDefinition(line int) bool {
+	i := slices.IndexFunc(r.overrode, func(d Definition) bool { return d.Line == line })
+	if i < 0 {
+		return false
+	}
+	r.overrode = slices.Delete(r.overrode, i, i+1)
+	if j := slices.IndexFunc(r.shadows, func(s shadow) bool { return s.def == line }); j >= 0 {
+		r.removeShadowAt(j)
+	}
+	return true
+}
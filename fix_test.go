@@ -0,0 +1,108 @@
+package envi_test
+
+import (
+	"slices"
+	"testing"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+func TestApplyFixes(t *testing.T) {
+	t.Parallel()
+
+	const src = "# top\napp-name=x\n\nPASS=a$b   # note\nKEEP =  'spaced'\n# DUP=0\nDUP=1\nMID=m\nDUP=2\nEMPTY=\n"
+	e, rep, err := envi.CheckString(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []envi.Fix{
+		{Op: envi.FixRewrite, Key: "APP_NAME", Line: 2, Msg: "write the key as APP_NAME"},
+		{Op: envi.FixRewrite, Key: "PASS", Line: 4, Msg: "quote the value"},
+		{Op: envi.FixDropValue, Key: "DUP", Line: 7, Msg: "remove the definition on line 7"},
+	}
+	if got := rep.Fixes(); !slices.Equal(got, want) {
+		t.Fatalf("Fixes =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := e.ApplyFixes(rep.Fixes()...); !slices.Equal(got, want) {
+		t.Errorf("applied %+v", got)
+	}
+	// The fixed lines change and nothing else does: not the spacing of KEEP,
+	// nor the genuine shadow of DUP.
+	const fixed = "# top\nAPP_NAME=x\n\nPASS=\"a\\$b\" # note\nKEEP =  'spaced'\n# DUP=0\nDUP=2\nMID=m\nEMPTY=\n"
+	if got := e.String(); got != fixed {
+		t.Errorf("got\n%s\nwant\n%s", got, fixed)
+	}
+	if defs := e.Explain("DUP"); len(defs) != 1 {
+		t.Errorf("Explain(DUP) = %v, want only the surviving statement", defs)
+	}
+
+	// What is left is what no fix resolves.
+	_, rep, _ = envi.CheckString(e.String())
+	if got := rulesOf(rep); !slices.Equal(got, []envi.Rule{envi.RuleEmptyValue}) {
+		t.Errorf("after fixing: rules = %v", got)
+	}
+}
+
+// Dropping a duplicate removes its line alone: not the blank line or the
+// comment above it, and not a genuine shadow that happens to hold its value.
+func TestApplyFixesDropsOnlyTheDefinition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct{ src, want string }{
+		{"A=1\n\n# about b\nB=x\nb=y\nZ=1\n", "A=1\n\n# about b\nB=y\nZ=1\n"},
+		{"A=1\n\nB=x\nB=y\n", "A=1\n\nB=y\n"},
+		{"A=1\n\n# B=x # old\nB=x\nB=y\n", "A=1\n\n# B=x # old\nB=y\n"},
+		{"# B=x\nB=y\nB=x\nB=z\n", "# B=x\nB=z\n"},
+	}
+	for _, tt := range tests {
+		e, rep, err := envi.CheckString(tt.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.ApplyFixes(rep.Fixes()...)
+		if got := e.String(); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestApplyFixesSkipsOverlaps(t *testing.T) {
+	t.Parallel()
+
+	// Two findings of one line with the same fix are both resolved by it.
+	e, rep, _ := envi.CheckString("pass-word=a$b\n")
+	if got := e.ApplyFixes(rep.Fixes()...); len(got) != 2 {
+		t.Errorf("applied %+v, want both", got)
+	}
+	if got := e.String(); got != "PASS_WORD=\"a\\$b\"\n" {
+		t.Errorf("got %q", got)
+	}
+
+	// Quoting a value writes the key in canonical form as well. A space needs
+	// the quotes only for a shell, so they are what the fix adds.
+	e, rep, _ = envi.CheckString("app-name=has space # note\n")
+	if got := e.ApplyFixes(rep.Fixes()...); len(got) != 2 {
+		t.Errorf("applied %+v, want both", got)
+	}
+	if got := e.String(); got != "APP_NAME=\"has space\" # note\n" {
+		t.Errorf("got %q", got)
+	}
+
+	// Removing a line makes rewriting it moot.
+	e, rep, _ = envi.CheckString("dup-key=1\nDUP_KEY=2\n")
+	got := e.ApplyFixes(rep.Fixes()...)
+	if len(got) != 1 || got[0].Op != envi.FixDropValue {
+		t.Errorf("applied %+v, want the removal alone", got)
+	}
+	if e.String() != "DUP_KEY=2\n" {
+		t.Errorf("got %q", e.String())
+	}
+
+	// A fix whose row has gone is skipped.
+	e, rep, _ = envi.CheckString("app-name=x\n")
+	e.Delete("APP_NAME")
+	if got := e.ApplyFixes(rep.Fixes()...); len(got) != 0 {
+		t.Errorf("applied %+v to a deleted row", got)
+	}
+}
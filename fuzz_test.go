@@ -241,6 +241,20 @@ func FuzzCheck(f *testing.F) {
 		if perr != nil && syntax < 1 {
 			t.Fatalf("Parse failed with %v but the checker found no syntax problem", perr)
 		}
+
+		// A fix changes how the file is written, never what it configures.
+		if perr != nil {
+			return
+		}
+		before := configuredOf(env)
+		env.ApplyFixes(rep.Fixes()...)
+		fixed, err := envi.ParseString(env.String())
+		if err != nil {
+			t.Fatalf("fixed document does not parse: %v\n%s", err, env)
+		}
+		if got := configuredOf(fixed); !maps.Equal(got, before) {
+			t.Fatalf("fixing changed the configuration:\nbefore %v\nafter  %v", before, got)
+		}
 	})
 }
 
@@ -402,3 +416,30 @@ func configuredOf(e *envi.Env) map[string]string {
 	}
 	return out
 }
+
+// FuzzDocument asserts that the JSON document is lossless: whatever a document
+// writes, the document read back from its JSON writes too, byte for byte.
+func FuzzDocument(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Add("A=1\r\n\r\n# c\r\nB_X=2\r\n")
+
+	f.Fuzz(func(t *testing.T, in string) {
+		first, err := envi.ParseString(in)
+		if err != nil {
+			t.Skip() // malformed input is FuzzParse's business
+		}
+		data, err := first.MarshalDocument()
+		if err != nil {
+			t.Skip() // not UTF-8, which JSON cannot carry
+		}
+		var second envi.Env
+		if err := second.UnmarshalDocument(data); err != nil {
+			t.Fatalf("our own document does not read back: %v\n%s", err, data)
+		}
+		if want, got := first.String(), second.String(); got != want {
+			t.Fatalf("document lost something\ninput: %q\nwant:  %q\ngot:   %q\n%s", in, want, got, data)
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package envi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// FromMap builds a document from m, its keys normalised and sorted and the
+// rows grouped into blocks by prefix, the way [Env.Regroup] groups them.
+//
+// Two keys of m that normalise alike, such as "db-host" and "DB_HOST", make
+// one row; the value is that of the key sorting last.
+func FromMap(m map[string]string) *Env {
+	e := New()
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		e.Set(k, m[k])
+	}
+	e.Regroup()
+	return e
+}
+
+// ImportJSON reads a JSON object and builds a document from it, as [FromMap]
+// does: {"db_host": "localhost"} becomes DB_HOST=localhost.
+//
+// Strings are taken as they are, numbers in the form they were written, true
+// and false as words, and null as an empty value. A nested object or an array
+// is an error unless flatten is set, which joins keys along the path:
+//
+//	{"db": {"host": "localhost", "replicas": ["a", "b"]}}
+//
+// becomes DB_HOST, DB_REPLICAS_0 and DB_REPLICAS_1, gathered into a DB block.
+// Two paths that arrive at the same key — "db_host" beside {"db": {"host"}} —
+// are an error naming both.
+func ImportJSON(r io.Reader, flatten bool) (*Env, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("envi: json: %w", err)
+	}
+	if dec.More() {
+		return nil, errors.New("envi: json: more than one value")
+	}
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return nil, errors.New("envi: json: want an object at the top level")
+	}
+
+	return newFlattener("json", flatten).build(obj)
+}
+
+// A flattener collects the rows of a nested document — a JSON object, a
+// [Env.Tree] — keyed by their normalised key, with the path each came from for
+// reporting a collision.
+type flattener struct {
+	what    string // the kind of input, for error messages
+	flatten bool
+	values  map[string]string
+	paths   map[string]string
+}
+
+func newFlattener(what string, flatten bool) *flattener {
+	return &flattener{what: what, flatten: flatten, values: map[string]string{}, paths: map[string]string{}}
+}
+
+// build flattens obj and returns the document it makes.
+func (im *flattener) build(obj map[string]any) (*Env, error) {
+	for _, k := range slices.Sorted(maps.Keys(obj)) {
+		if err := im.add(k, k, obj[k]); err != nil {
+			return nil, err
+		}
+	}
+	return FromMap(im.values), nil
+}
+
+// add records value, found at path, under key, descending into it when it is
+// an object or an array.
+func (im *flattener) add(path, key string, value any) error {
+	switch v := value.(type) {
+	case map[string]any:
+		if !im.flatten {
+			return fmt.Errorf("envi: %s: %s: a nested object needs flattening", im.what, path)
+		}
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			if k == "" {
+				// It would leave the parent's name with a separator trailing.
+				return fmt.Errorf("envi: %s: %s: an empty name makes no key", im.what, path+".")
+			}
+			if err := im.add(path+"."+k, key+"_"+k, v[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []any:
+		if !im.flatten {
+			return fmt.Errorf("envi: %s: %s: an array needs flattening", im.what, path)
+		}
+		for i, el := range v {
+			if err := im.add(path+"["+strconv.Itoa(i)+"]", key+"_"+strconv.Itoa(i), el); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	k := NormalizeKey(key)
+	if k == "" || k == string(blockSeparator) {
+		return fmt.Errorf("envi: %s: %s: the name makes no key", im.what, path)
+	}
+	if prev, dup := im.paths[k]; dup {
+		return fmt.Errorf("envi: %s: %s and %s both become %s", im.what, prev, path, k)
+	}
+	s, ok := scalar(value)
+	if !ok {
+		return fmt.Errorf("envi: %s: %s: a value of type %T", im.what, path, value)
+	}
+	im.paths[k] = path
+	im.values[k] = s
+	return nil
+}
+
+// scalar renders a scalar as a value: a string as it is, a number in its
+// shortest form, true and false as words, and nil as an empty value. It reports
+// false for anything else.
+func scalar(v any) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// Import updates e with what src configures and returns what it changed: the
+// way to bring a file in line with an export from somewhere else — a secret
+// manager's JSON dump, a properties file — with a diff showing only that.
+//
+// A key e lacks is added as a [ChangeAdded], joining the block of its prefix
+// or, when e has no row of that prefix, arriving in a block like the one src
+// keeps it in. A key whose value differs is set as a [ChangeChanged], which
+// rewrites that line alone; a row e has commented out is brought back to life,
+// since src configures it. A key with the same value is left untouched, and so
+// is every key src does not mention. Changes come in the order of src. A nil
+// src changes nothing.
+func (e *Env) Import(src *Env) *Delta {
+	d := &Delta{}
+	if src == nil {
+		return d
+	}
+	for it := range src.Items() {
+		switch v := it.(type) {
+		case *Row:
+			e.importRow(d, v, nil)
+		case *Block:
+			for _, r := range v.rows {
+				e.importRow(d, r, v)
+			}
+		}
+	}
+	return d
+}
+
+// importRow brings one row of src into e. from is the block holding it in
+// src, nil at top level.
+func (e *Env) importRow(d *Delta, sr *Row, from *Block) {
+	if sr.commented {
+		return
+	}
+	r := e.Get(sr.key)
+	switch {
+	case r == nil:
+		e.addLike(sr, from)
+		d.changes = append(d.changes, Change{Kind: ChangeAdded, Key: sr.key, New: sr.value})
+	case r.commented:
+		d.changes = append(d.changes, Change{Kind: ChangeAdded, Key: sr.key, New: sr.value})
+		r.SetCommented(false)
+		if r.value != sr.value {
+			r.replaceValue(sr.value)
+		}
+	case r.value != sr.value:
+		d.changes = append(d.changes, Change{Kind: ChangeChanged, Key: sr.key, Old: r.value, New: sr.value})
+		r.replaceValue(sr.value)
+	}
+}
@@ -0,0 +1,101 @@
+package envi_test
+
+import (
+	"strings"
+	"testing"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+func TestFromMap(t *testing.T) {
+	t.Parallel()
+
+	e := envi.FromMap(map[string]string{"db-port": "5432", "DB_HOST": "localhost", "name": "app"})
+	if got, want := e.String(), "DB_HOST=localhost\nDB_PORT=5432\n\nNAME=app\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if e.Block("DB") == nil {
+		t.Error("DB rows were not grouped into a block")
+	}
+}
+
+func TestImportJSON(t *testing.T) {
+	t.Parallel()
+
+	const src = `{"name": "app", "port": 8080, "ratio": 1.50, "debug": false, "none": null,
+		"db": {"host": "localhost", "replicas": ["a", {"zone": "eu"}]}}`
+
+	if _, err := envi.ImportJSON(strings.NewReader(src), false); err == nil || !strings.Contains(err.Error(), "db: a nested object") {
+		t.Errorf("nested without flatten: err = %v", err)
+	}
+
+	e, err := envi.ImportJSON(strings.NewReader(src), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"NAME": "app", "PORT": "8080", "RATIO": "1.50", "DEBUG": "false", "NONE": "",
+		"DB_HOST": "localhost", "DB_REPLICAS_0": "a", "DB_REPLICAS_1_ZONE": "eu",
+	}
+	if e.Len() != len(want) {
+		t.Errorf("got %d rows, want %d:\n%s", e.Len(), len(want), e)
+	}
+	for k, v := range want {
+		if got, ok := e.Lookup(k); !ok || got != v {
+			t.Errorf("%s = %q, %v; want %q", k, got, ok, v)
+		}
+	}
+	if b := e.Block("DB"); b == nil || b.Len() != 3 {
+		t.Errorf("DB block = %v", b)
+	}
+
+	for name, bad := range map[string]string{
+		"collision":  `{"db_host": "a", "db": {"host": "b"}}`,
+		"array":      `["a"]`,
+		"trailing":   `{} {}`,
+		"empty key":  `{"": "x"}`,
+		"empty name": `{"a": {"": "x"}}`,
+		"malformed":  `{"a": }`,
+	} {
+		if _, err := envi.ImportJSON(strings.NewReader(bad), true); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+	if _, err := envi.ImportJSON(strings.NewReader(`{"db_host": "a", "db": {"host": "b"}}`), true); err == nil ||
+		!strings.Contains(err.Error(), "db.host and db_host both become DB_HOST") {
+		t.Errorf("collision: err = %v", err)
+	}
+}
+
+func TestImportKeepsTheDiffMinimal(t *testing.T) {
+	t.Parallel()
+
+	const src = "# the database\nDB_HOST=old # primary\nDB_PORT='5432'\n\n# OLD_TOKEN=abc\nNAME=app\n"
+	e, err := envi.ParseString(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, err := envi.ImportJSON(strings.NewReader(`{"db": {"host": "new", "port": "5432", "user": "u"}, "old_token": "t", "cache": {"ttl": "5"}}`), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := e.Import(in)
+	// CACHE has no block here, so one is started for it, at the end.
+	const want = "# the database\nDB_HOST=new # primary\nDB_PORT='5432'\nDB_USER=u\n\nOLD_TOKEN=t\nNAME=app\nCACHE_TTL=5\n"
+	if got := e.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	var kinds []string
+	for c := range d.All() {
+		kinds = append(kinds, c.Kind.String()+" "+c.Key)
+	}
+	if got, want := strings.Join(kinds, ", "), "added CACHE_TTL, changed DB_HOST, added DB_USER, added OLD_TOKEN"; got != want {
+		t.Errorf("changes = %s, want %s", got, want)
+	}
+
+	if d := e.Import(in); d.Len() != 0 {
+		t.Errorf("importing again changed %d keys", d.Len())
+	}
+}
@@ -0,0 +1,130 @@
+package envi
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"slices"
+	"strings"
+)
+
+// signaturePrefix starts the trailer line carrying a document's MAC. The rest
+// of the line is the MAC in unpadded URL-safe base64. Being a comment, the line
+// means nothing to any other reader of the file; the "@" keeps this package
+// from reading it as a commented-out assignment.
+const signaturePrefix = "# @envi-mac v1 "
+
+// macLabel is the HKDF info string the MAC key is derived with. The MAC is an
+// HMAC-SHA256 under HKDF-SHA256(key, no salt, macLabel), not under the key as
+// given, so that a key that also encrypts values — the content of a key file
+// of package crypt, say — is never used as it is for both.
+const macLabel = "envi-mac v1"
+
+// An IntegrityError reports a document whose integrity trailer does not vouch
+// for what it configures: a row was added, removed or changed since it was
+// signed, or it was never signed at all. See [WithVerify].
+type IntegrityError struct {
+	// Unsigned is true when the document carries no integrity trailer, as
+	// opposed to one that does not match.
+	Unsigned bool
+}
+
+// Error implements the error interface.
+func (e *IntegrityError) Error() string {
+	if e.Unsigned {
+		return "envi: document carries no integrity trailer"
+	}
+	return "envi: integrity check failed: a configured row was added, removed or changed since the document was signed"
+}
+
+// WithSign writes an integrity trailer when encoding: a comment line at the end
+// of the document carrying an HMAC-SHA256, under a key derived from key, of
+// what the document configures. A trailer already there is replaced, and one
+// that rows were appended after is dropped. Encoding only.
+//
+//	err := envi.Save(env, ".env.production", envi.WithSign(key))
+//
+// The MAC covers the configured rows — live keys and their values as stored,
+// encrypted or not — and nothing else, so comments and layout can be edited
+// freely. Adding, removing or changing a row by any means, this package's own
+// included, calls for signing again.
+func WithSign(key []byte) Option {
+	k := slices.Clone(key)
+	return optionFunc(func(c *config) { c.signKey = k })
+}
+
+// WithVerify checks the integrity trailer when parsing: a document that carries
+// none, or one that does not match what it configures, fails with an
+// [*IntegrityError]. With [LoadWith] every file must be signed. Parsing only;
+// [Check] ignores it.
+func WithVerify(key []byte) Option {
+	k := slices.Clone(key)
+	return optionFunc(func(c *config) { c.verifyKey = k })
+}
+
+// Verify checks the document's integrity trailer against key, the way
+// [WithVerify] does while parsing. It returns nil or an [*IntegrityError].
+func (e *Env) Verify(key []byte) error {
+	line := ""
+	for _, l := range e.trailer {
+		if isSignature(l) {
+			line = l
+		}
+	}
+	if line == "" {
+		return &IntegrityError{Unsigned: true}
+	}
+	got, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(line)[len(signaturePrefix):])
+	if err != nil || !hmac.Equal(got, e.mac(key)) {
+		return &IntegrityError{}
+	}
+	return nil
+}
+
+// signature returns the trailer line vouching for the document under key.
+func (e *Env) signature(key []byte) string {
+	return signaturePrefix + base64.RawURLEncoding.EncodeToString(e.mac(key))
+}
+
+// mac computes the MAC of what the document configures. Rows are taken in key
+// order, so that moving one is not a change, and every key and value is
+// length-prefixed, so that no two different sets of rows feed the hash the same
+// bytes.
+func (e *Env) mac(key []byte) []byte {
+	type pair struct{ key, value string }
+	var rows []pair
+	for r := range e.Rows() {
+		if !r.commented {
+			rows = append(rows, pair{r.key, r.value})
+		}
+	}
+	slices.SortFunc(rows, func(a, b pair) int { return strings.Compare(a.key, b.key) })
+
+	h := hmac.New(sha256.New, deriveMACKey(key))
+	var n [binary.MaxVarintLen64]byte
+	for _, p := range rows {
+		for _, s := range [...]string{p.key, p.value} {
+			h.Write(n[:binary.PutUvarint(n[:], uint64(len(s)))])
+			h.Write([]byte(s))
+		}
+	}
+	return h.Sum(nil)
+}
+
+// deriveMACKey returns the key the MAC is made with: see macLabel.
+func deriveMACKey(key []byte) []byte {
+	k, err := hkdf.Key(sha256.New, key, nil, macLabel, sha256.Size)
+	if err != nil {
+		// HKDF fails only for a length it cannot produce, and one hash's
+		// worth it always can.
+		panic(err)
+	}
+	return k
+}
+
+// isSignature reports whether a line is an integrity trailer.
+func isSignature(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), signaturePrefix)
+}
@@ -0,0 +1,152 @@
+package envi_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+var macKey = []byte("0123456789abcdef0123456789abcdef")
+
+// signed renders src with an integrity trailer under macKey.
+func signed(t *testing.T, src string) string {
+	t.Helper()
+
+	var b strings.Builder
+	if err := envi.NewEncoder(&b, envi.WithSign(macKey)).Encode(mustParse(t, src)); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestSignKeepsTheDocument(t *testing.T) {
+	t.Parallel()
+
+	const src = "# app\nAPP_NAME=shop\n\n# trailing note\n"
+	out := signed(t, src)
+	if !strings.HasPrefix(out, src) {
+		t.Fatalf("signing changed the document:\n%s", out)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if last := lines[len(lines)-1]; !strings.HasPrefix(last, "# @envi-mac v1 ") {
+		t.Errorf("last line = %q, want the trailer", last)
+	}
+
+	// Signing again replaces the trailer rather than stacking another.
+	if again := signed(t, out); again != out {
+		t.Errorf("re-signing =\n%s\nwant\n%s", again, out)
+	}
+}
+
+func TestSignDropsAStaleTrailer(t *testing.T) {
+	t.Parallel()
+
+	// Rows appended after a trailer leave it mid-file; signing again must not
+	// keep it there as a comment.
+	out := signed(t, signed(t, "A=1\n")+"B=2\n")
+	if n := strings.Count(out, "# @envi-mac v1 "); n != 1 {
+		t.Errorf("%d trailers:\n%s", n, out)
+	}
+	if !strings.HasPrefix(out, "A=1\nB=2\n# @envi-mac v1 ") {
+		t.Errorf("got\n%s", out)
+	}
+	if _, err := envi.ParseString(out, envi.WithVerify(macKey)); err != nil {
+		t.Errorf("re-signed: %v", err)
+	}
+}
+
+func TestSignDerivesItsKey(t *testing.T) {
+	t.Parallel()
+
+	// The trailer is not an HMAC under the key as given, which may be a key
+	// that encrypts values as well.
+	out := signed(t, "A=1\n")
+	h := hmac.New(sha256.New, macKey)
+	h.Write([]byte{1, 'A', 1, '1'})
+	if strings.Contains(out, base64.RawURLEncoding.EncodeToString(h.Sum(nil))) {
+		t.Errorf("the trailer is made with the key itself:\n%s", out)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	out := signed(t, "A=1\nB=2\n# C=3\n")
+	if _, err := envi.ParseString(out, envi.WithVerify(macKey)); err != nil {
+		t.Fatalf("a freshly signed document: %v", err)
+	}
+
+	for name, doc := range map[string]string{
+		"changed":   strings.Replace(out, "A=1", "A=9", 1),
+		"removed":   strings.Replace(out, "B=2\n", "", 1),
+		"added":     "Z=1\n" + out,
+		"uncomment": strings.Replace(out, "# C=3", "C=3", 1),
+		"wrong key": out,
+	} {
+		key := macKey
+		if name == "wrong key" {
+			key = []byte("another key")
+		}
+		_, err := envi.ParseString(doc, envi.WithVerify(key))
+		var ie *envi.IntegrityError
+		if !errors.As(err, &ie) || ie.Unsigned {
+			t.Errorf("%s: err = %v, want a mismatch", name, err)
+		}
+	}
+
+	for name, doc := range map[string]string{
+		"comments":  strings.Replace(out, "A=1\n", "# about a\nA=1 # still one\n\n", 1),
+		"reordered": strings.Replace(out, "A=1\nB=2\n", "B=2\nA=1\n", 1),
+		"quoted":    strings.Replace(out, "A=1", `A="1"`, 1),
+		"shadow":    strings.Replace(out, "A=1", "# A=0\nA=1", 1),
+	} {
+		if _, err := envi.ParseString(doc, envi.WithVerify(macKey)); err != nil {
+			t.Errorf("%s: %v, want layout edits to pass", name, err)
+		}
+	}
+
+	_, err := envi.ParseString("A=1\n", envi.WithVerify(macKey))
+	var ie *envi.IntegrityError
+	if !errors.As(err, &ie) || !ie.Unsigned {
+		t.Errorf("unsigned: err = %v", err)
+	}
+
+	// A row appended after the trailer leaves it mid-file, where it vouches
+	// for nothing.
+	if _, err := envi.ParseString(out+"EVIL=1\n", envi.WithVerify(macKey)); !errors.As(err, &ie) {
+		t.Errorf("appended row: err = %v", err)
+	}
+}
+
+func TestLoadWithVerify(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	if err := envi.Save(mustParse(t, "A=1\n"), path, envi.WithSign(macKey)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := envi.LoadWith([]envi.Option{envi.WithVerify(macKey)}, path); err != nil {
+		t.Errorf("LoadWith: %v", err)
+	}
+
+	b, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, []byte(strings.Replace(string(b), "A=1", "A=2", 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var ie *envi.IntegrityError
+	if _, err := envi.LoadWith([]envi.Option{envi.WithVerify(macKey)}, path); !errors.As(err, &ie) {
+		t.Errorf("LoadWith on a tampered file: err = %v", err)
+	}
+	// Check reports on the file as written and leaves integrity alone.
+	if _, _, err := envi.CheckFile(path, envi.WithVerify(macKey)); err != nil {
+		t.Errorf("CheckFile: %v", err)
+	}
+}
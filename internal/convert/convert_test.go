@@ -0,0 +1,27 @@
+package convert
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// setViaText refuses a value it cannot take the address of, rather than
+// panicking inside reflect. Struct fields are always addressable, so this
+// guard is unreachable through the exported API — which is exactly why it is
+// worth pinning here.
+func TestSetViaTextRejectsUnaddressableValue(t *testing.T) {
+	t.Parallel()
+
+	unaddressable := reflect.ValueOf(time.Time{})
+	if unaddressable.CanAddr() {
+		t.Fatal("precondition: the value must not be addressable")
+	}
+
+	err := setViaText(unaddressable, "2026-08-12T10:00:00Z")
+	var ue *UnsupportedError
+	if !errors.As(err, &ue) || ue.Type != unaddressable.Type() {
+		t.Errorf("error = %v, want an *UnsupportedError for time.Time", err)
+	}
+}
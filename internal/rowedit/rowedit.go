@@ -0,0 +1,10 @@
+// Package rowedit lets the other packages of the module edit a row of package
+// envi in place, an operation the public API leaves out: [envi.Row.SetValue]
+// writes the row afresh, which is what a caller building a document wants.
+package rowedit
+
+// ReplaceValue gives row, an *envi.Row, a new value while keeping the lines
+// recorded above it — comments, shadows, blank lines — so that a file written
+// back differs in that row's line alone. Package envi sets it when it is
+// initialised, before any package importing it can call it.
+var ReplaceValue func(row any, value string)
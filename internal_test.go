@@ -78,7 +78,7 @@ func TestFoldDuplicateCarriesShadows(t *testing.T) {
 	t.Parallel()
 
 	prev := &Row{key: "K", value: "first"}
-	next := &Row{key: "K", value: "second", shadows: []string{"alt-a", "alt-b"}}
+	next := &Row{key: "K", value: "second", shadows: []shadow{{value: "alt-a"}, {value: "alt-b"}}}
 
 	foldDuplicate(prev, next, false)
 
@@ -86,8 +86,8 @@ func TestFoldDuplicateCarriesShadows(t *testing.T) {
 		t.Errorf("value = %q, want the later definition", prev.value)
 	}
 	want := []string{"first", "alt-a", "alt-b"}
-	if !slices.Equal(prev.shadows, want) {
-		t.Errorf("shadows = %v, want %v", prev.shadows, want)
+	if got := slices.Collect(prev.Shadows()); !slices.Equal(got, want) {
+		t.Errorf("shadows = %v, want %v", got, want)
 	}
 	if prev.parsed || prev.rawLine != "" {
 		t.Error("a folded row must give up its verbatim rendering")
@@ -0,0 +1,274 @@
+// Package k8s writes what a .env document configures as a Kubernetes manifest:
+// a ConfigMap, or a Secret for values that should not sit in one.
+//
+//	env, err := envi.Load(".env")
+//	err = k8s.Encode(os.Stdout, env, k8s.Manifest{Name: "app", Namespace: "prod"})
+//
+// produces
+//
+//	apiVersion: v1
+//	kind: ConfigMap
+//	metadata:
+//	  name: app
+//	  namespace: prod
+//	data:
+//	  DB_HOST: "db"
+//
+// The YAML is written by hand, so the module needs no YAML library. Every value
+// is double-quoted, which is the one YAML form that reads back as the same
+// string whatever it holds: "true", "0755" and "null" stay strings, and a
+// multi-line value keeps its line breaks.
+//
+// Kubernetes is stricter about names than a .env file. Data keys are checked
+// against the rules the API server applies, and so are the name and namespace,
+// so a manifest that is written is one kubectl will take.
+package k8s
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// AnnotationPrefix starts the annotation that carries a row's comment when
+// [Manifest.Annotations] asks for them: the comment of DB_HOST is annotated as
+// "envi/DB_HOST".
+const AnnotationPrefix = "envi/"
+
+// Limits the API server puts on names.
+const (
+	maxKeyLen        = 253 // a data key, and a DNS subdomain such as an object name
+	maxLabelLen      = 63  // a DNS label such as a namespace
+	maxAnnotationLen = 63  // the name part of an annotation key
+)
+
+// A Manifest describes the object to write.
+type Manifest struct {
+	// Name is the object's name, a DNS subdomain: lower case letters, digits,
+	// '-' and '.'. It is required.
+	Name string
+
+	// Namespace is the object's namespace, a DNS label. Empty leaves it out,
+	// for kubectl to fill in.
+	Namespace string
+
+	// Secret writes a Secret of type Opaque, its values base64-encoded under
+	// data, instead of a ConfigMap.
+	Secret bool
+
+	// Annotations carries each row's comment, the lines above it and the one
+	// trailing it, into an annotation named [AnnotationPrefix] plus the key.
+	Annotations bool
+}
+
+// Encode writes the rows e configures as the manifest m describes. A
+// commented-out row configures nothing and is left out; keys are written in
+// sorted order, so the output is the same every run.
+//
+// Nothing is written unless the whole manifest is valid. The error lists every
+// name that breaks a Kubernetes rule, each with the rule it breaks.
+func Encode(w io.Writer, e *envi.Env, m Manifest) error {
+	rows := configured(e)
+	if err := m.validate(rows); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("apiVersion: v1\n")
+	if m.Secret {
+		bw.WriteString("kind: Secret\n")
+	} else {
+		bw.WriteString("kind: ConfigMap\n")
+	}
+	bw.WriteString("metadata:\n")
+	fmt.Fprintf(bw, "  name: %s\n", scalar(m.Name))
+	if m.Namespace != "" {
+		fmt.Fprintf(bw, "  namespace: %s\n", scalar(m.Namespace))
+	}
+	if m.Annotations {
+		writeAnnotations(bw, rows)
+	}
+	if m.Secret {
+		bw.WriteString("type: Opaque\n")
+	}
+
+	if len(rows) == 0 {
+		bw.WriteString("data: {}\n")
+		return bw.Flush()
+	}
+	bw.WriteString("data:\n")
+	for _, r := range rows {
+		v := r.Value()
+		if m.Secret {
+			v = base64.StdEncoding.EncodeToString([]byte(v))
+		}
+		fmt.Fprintf(bw, "  %s: %s\n", scalar(r.Key()), strconv.Quote(v))
+	}
+	return bw.Flush()
+}
+
+// writeAnnotations writes the comments of rows, if any row has one.
+func writeAnnotations(bw *bufio.Writer, rows []*envi.Row) {
+	first := true
+	for _, r := range rows {
+		c := comment(r)
+		if c == "" {
+			continue
+		}
+		if first {
+			bw.WriteString("  annotations:\n")
+			first = false
+		}
+		fmt.Fprintf(bw, "    %s: %s\n", scalar(AnnotationPrefix+r.Key()), strconv.Quote(c))
+	}
+}
+
+// comment returns what a row says about itself: the comment above it and the
+// one trailing it, one per line.
+func comment(r *envi.Row) string {
+	var parts []string
+	for _, c := range []string{r.Comment(), r.InlineComment()} {
+		if c = strings.TrimSpace(c); c != "" {
+			parts = append(parts, c)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// configured returns the rows e configures, sorted by key.
+func configured(e *envi.Env) []*envi.Row {
+	var rows []*envi.Row
+	for r := range e.Rows() {
+		if !r.IsCommented() {
+			rows = append(rows, r)
+		}
+	}
+	slices.SortFunc(rows, func(a, b *envi.Row) int { return strings.Compare(a.Key(), b.Key()) })
+	return rows
+}
+
+// validate checks every name the manifest would carry.
+func (m Manifest) validate(rows []*envi.Row) error {
+	var errs []error
+	if m.Name == "" {
+		errs = append(errs, errors.New("k8s: a manifest needs a name"))
+	} else if msg := checkSubdomain(m.Name); msg != "" {
+		errs = append(errs, fmt.Errorf("k8s: name %q: %s", m.Name, msg))
+	}
+	if m.Namespace != "" {
+		if msg := checkLabel(m.Namespace); msg != "" {
+			errs = append(errs, fmt.Errorf("k8s: namespace %q: %s", m.Namespace, msg))
+		}
+	}
+	for _, r := range rows {
+		if msg := checkKey(r.Key()); msg != "" {
+			errs = append(errs, fmt.Errorf("k8s: key %s: %s", r.Key(), msg))
+			continue
+		}
+		if !m.Secret && !utf8.ValidString(r.Value()) {
+			errs = append(errs, fmt.Errorf("k8s: key %s: the value is not valid UTF-8, which a ConfigMap cannot hold; write a Secret", r.Key()))
+		}
+		if c := comment(r); m.Annotations && c != "" {
+			if msg := checkAnnotation(r.Key()); msg != "" {
+				errs = append(errs, fmt.Errorf("k8s: key %s: cannot name an annotation for its comment: %s", r.Key(), msg))
+			} else if !utf8.ValidString(c) {
+				errs = append(errs, fmt.Errorf("k8s: key %s: the comment is not valid UTF-8", r.Key()))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkKey applies the rule for a ConfigMap or Secret data key, returning what
+// is wrong with key or "" when nothing is.
+func checkKey(key string) string {
+	switch {
+	case len(key) > maxKeyLen:
+		return fmt.Sprintf("longer than %d characters", maxKeyLen)
+	case key == "." || key == "..":
+		return `must not be "." or ".."`
+	case strings.HasPrefix(key, ".."):
+		return `must not start with ".."`
+	}
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; !isAlnum(c) && c != '-' && c != '_' && c != '.' {
+			return "may hold only letters, digits, '-', '_' and '.'"
+		}
+	}
+	return ""
+}
+
+// checkAnnotation applies the rule for the name part of an annotation key.
+func checkAnnotation(name string) string {
+	if len(name) > maxAnnotationLen {
+		return fmt.Sprintf("longer than %d characters", maxAnnotationLen)
+	}
+	if !isAlnum(name[0]) || !isAlnum(name[len(name)-1]) {
+		return "must start and end with a letter or digit"
+	}
+	return ""
+}
+
+// checkSubdomain applies the rule for an object name, an RFC 1123 subdomain.
+func checkSubdomain(s string) string {
+	if len(s) > maxKeyLen {
+		return fmt.Sprintf("longer than %d characters", maxKeyLen)
+	}
+	for label := range strings.SplitSeq(s, ".") {
+		if !isLabel(label) {
+			return "must be lower case letters, digits, '-' and '.', starting and ending with a letter or digit"
+		}
+	}
+	return ""
+}
+
+// checkLabel applies the rule for a namespace, an RFC 1123 label.
+func checkLabel(s string) string {
+	if len(s) > maxLabelLen {
+		return fmt.Sprintf("longer than %d characters", maxLabelLen)
+	}
+	if !isLabel(s) {
+		return "must be lower case letters, digits and '-', starting and ending with a letter or digit"
+	}
+	return ""
+}
+
+// isLabel reports whether s is an RFC 1123 label, leaving its length aside.
+func isLabel(s string) bool {
+	if s == "" || s[0] == '-' || s[len(s)-1] == '-' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// scalar writes a name that has passed validation as a YAML scalar. Such a
+// name is plain text to YAML unless it spells a boolean, a null or a number,
+// which would then be read back as something other than a string; those are
+// quoted.
+func scalar(s string) string {
+	switch strings.ToLower(s) {
+	case "y", "yes", "n", "no", "true", "false", "on", "off", "null", "~":
+		return strconv.Quote(s)
+	}
+	if s[0] >= '0' && s[0] <= '9' || s[0] == '-' || s[0] == '.' {
+		return strconv.Quote(s)
+	}
+	return s
+}
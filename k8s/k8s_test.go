@@ -0,0 +1,121 @@
+package k8s_test
+
+import (
+	"strings"
+	"testing"
+
+	envi "github.com/efureev/envi/v2"
+	"github.com/efureev/envi/v2/k8s"
+)
+
+func encode(t *testing.T, src string, m k8s.Manifest) (string, error) {
+	t.Helper()
+
+	e, err := envi.ParseString(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	err = k8s.Encode(&b, e, m)
+	return b.String(), err
+}
+
+func TestConfigMap(t *testing.T) {
+	t.Parallel()
+
+	const src = "# the database\nDB_HOST=db # primary\nDB_PORT=5432\n# OLD=gone\n" +
+		"FLAG=true\nY=yes\nMULTI=\"a\\nb \\\"c\\\"\"\n"
+	got, err := encode(t, src, k8s.Manifest{Name: "app", Namespace: "prod", Annotations: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: prod
+  annotations:
+    envi/DB_HOST: "the database\nprimary"
+data:
+  DB_HOST: "db"
+  DB_PORT: "5432"
+  FLAG: "true"
+  MULTI: "a\nb \"c\""
+  "Y": "yes"
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSecret(t *testing.T) {
+	t.Parallel()
+
+	got, err := encode(t, "# rotate monthly\nDB_PASSWORD=hunter2\n", k8s.Manifest{Name: "app", Secret: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+type: Opaque
+data:
+  DB_PASSWORD: "aHVudGVyMg=="
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if got, _ := encode(t, "# OLD=gone\n", k8s.Manifest{Name: "empty"}); !strings.HasSuffix(got, "data: {}\n") {
+		t.Errorf("nothing configured:\n%s", got)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		m    k8s.Manifest
+		want []string
+	}{
+		{"no name", "A=1\n", k8s.Manifest{}, []string{"needs a name"}},
+		{"bad name", "A=1\n", k8s.Manifest{Name: "My_App"}, []string{`name "My_App"`}},
+		{"bad namespace", "A=1\n", k8s.Manifest{Name: "app", Namespace: "prod.eu"}, []string{`namespace "prod.eu"`}},
+		{"dot key", "..A=1\n", k8s.Manifest{Name: "app"}, []string{`key ..A: must not start with ".."`}},
+		{"long key", strings.Repeat("A", 254) + "=1\n", k8s.Manifest{Name: "app"}, []string{"longer than 253"}},
+		{"binary in a ConfigMap", "A=\"\xff\"\n", k8s.Manifest{Name: "app"}, []string{"key A: the value is not valid UTF-8"}},
+		{
+			"annotation name", "# note\nA_=1\n", k8s.Manifest{Name: "app", Annotations: true},
+			[]string{"key A_: cannot name an annotation"},
+		},
+		{
+			"every problem at once", "..A=1\n", k8s.Manifest{Name: "App", Namespace: "-x"},
+			[]string{`name "App"`, `namespace "-x"`, "key ..A"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := encode(t, tt.src, tt.m)
+			if err == nil {
+				t.Fatalf("no error; wrote\n%s", got)
+			}
+			if got != "" {
+				t.Errorf("an invalid manifest was written:\n%s", got)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("error %q does not mention %q", err, w)
+				}
+			}
+		})
+	}
+
+	if _, err := encode(t, "A=\"\xff\"\n", k8s.Manifest{Name: "app", Secret: true}); err != nil {
+		t.Errorf("binary in a Secret: %v", err)
+	}
+}
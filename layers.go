@@ -0,0 +1,223 @@
+package envi
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// EnvironLayer is the name [Layers.Which] reports for a value supplied by the
+// process environment.
+const EnvironLayer = "(environment)"
+
+// Layers is a stack of documents consulted together, without merging them.
+//
+// [LoadWith] folds every file into one document up front, after which there is
+// no telling which file a value came from and no way to edit one file through
+// the result. Layers keeps each document whole and applies precedence when a
+// key is looked up instead, so a tool can read the effective configuration,
+// change one layer and save just that layer with its formatting intact:
+//
+//	l, err := envi.LoadLayers(".env", ".env.local")
+//	l.Layer(".env.local").Set("DB_HOST", "db.internal")
+//	err = l.Save(".env.local")
+//
+// Precedence is the one [Load] applies: a later layer overrides an earlier one,
+// and an empty value does not override a set one. The process environment, when
+// included with [Layers.SetEnviron], sits on top of every layer and wins
+// whenever it holds the variable at all.
+//
+// Layers satisfies the Source interface of the bind package, so a struct can be
+// filled from it directly.
+//
+// The zero Layers is an empty stack, ready to use.
+type Layers struct {
+	names []string
+	envs  []*Env
+
+	environ bool
+}
+
+// LoadLayers reads the named files as layers, the first at the bottom. With no
+// arguments it reads ".env", the same default [Load] takes.
+func LoadLayers(paths ...string) (*Layers, error) {
+	return LoadLayersWith(nil, paths...)
+}
+
+// LoadLayersWith is [LoadLayers] with parsing options.
+func LoadLayersWith(opts []Option, paths ...string) (*Layers, error) {
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+	l := &Layers{}
+	for _, path := range paths {
+		e, err := loadFile(path, opts)
+		if err != nil {
+			return nil, err
+		}
+		l.Push(path, e)
+	}
+	return l, nil
+}
+
+// Push adds e on top of the stack under name and returns l for chaining. For a
+// layer read from a file the name is its path, which is where [Layers.Save]
+// writes it. A nil e is ignored.
+func (l *Layers) Push(name string, e *Env) *Layers {
+	if e != nil {
+		l.names = append(l.names, name)
+		l.envs = append(l.envs, e)
+	}
+	return l
+}
+
+// SetEnviron puts the process environment on top of every layer, or takes it
+// away again, and returns l for chaining.
+//
+// The environment is consulted when a key is looked up rather than copied in,
+// so a variable set after this call is seen.
+func (l *Layers) SetEnviron(on bool) *Layers {
+	l.environ = on
+	return l
+}
+
+// Len returns the number of documents in the stack, not counting the process
+// environment.
+func (l *Layers) Len() int { return len(l.envs) }
+
+// Names returns the layer names from the bottom of the stack to the top.
+func (l *Layers) Names() []string { return slices.Clone(l.names) }
+
+// Layer returns the document pushed under name, or nil if there is none. It is
+// the document itself, not a copy, so editing it edits the layer. When a name
+// was pushed twice the upper one is returned.
+func (l *Layers) Layer(name string) *Env {
+	if i := l.index(name); i >= 0 {
+		return l.envs[i]
+	}
+	return nil
+}
+
+// index returns the position of the topmost layer called name, or -1.
+func (l *Layers) index(name string) int {
+	for i := len(l.names) - 1; i >= 0; i-- {
+		if l.names[i] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Lookup returns the value key resolves to across the stack and whether any
+// layer holds it.
+func (l *Layers) Lookup(key string) (string, bool) {
+	value, _, ok := l.resolve(NormalizeKey(key))
+	return value, ok
+}
+
+// Which returns the name of the layer the value of key comes from, and whether
+// any layer holds it. A value from the process environment is reported as
+// [EnvironLayer].
+//
+// When every layer holding the key leaves it empty, the lowest of them is
+// reported, which is also the file [Env.Explain] names for a merged document.
+func (l *Layers) Which(key string) (string, bool) {
+	_, at, ok := l.resolve(NormalizeKey(key))
+	switch {
+	case !ok:
+		return "", false
+	case at < 0:
+		return EnvironLayer, true
+	default:
+		return l.names[at], true
+	}
+}
+
+// resolve applies precedence to a normalised key. at is the index of the layer
+// supplying the value, or -1 for the process environment.
+func (l *Layers) resolve(key string) (value string, at int, ok bool) {
+	if l.environ {
+		if v, found := lookupEnviron(key); found {
+			return v, -1, true
+		}
+	}
+	at = -1
+	for i := len(l.envs) - 1; i >= 0; i-- {
+		r := l.envs[i].Get(key)
+		if r == nil {
+			continue
+		}
+		if r.value != "" {
+			return r.value, i, true
+		}
+		// An empty value states that the key exists, not that it is blank, so
+		// a lower layer still gets its say. Remember the lowest, for Which.
+		at = i
+	}
+	if at >= 0 {
+		return "", at, true
+	}
+	return "", -1, false
+}
+
+// lookupEnviron finds a process environment variable by its normalised name.
+// The exact name is tried first, which is the common case and costs no scan;
+// failing that, a variable spelled differently — app_port for APP_PORT — is
+// found the way normalising every name would have found it, the last one
+// winning.
+func lookupEnviron(key string) (string, bool) {
+	if v, ok := os.LookupEnv(key); ok {
+		return v, true
+	}
+	value, found := "", false
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok && NormalizeKey(k) == key {
+			value, found = v, true
+		}
+	}
+	return value, found
+}
+
+// Flatten merges the stack into one new document, the way [LoadWith] would have
+// read the same files, with the process environment set on top when it is
+// included. The layers are copied, so editing the result leaves them alone.
+func (l *Layers) Flatten() (*Env, error) {
+	out := &Env{}
+	for _, e := range l.envs {
+		if err := out.Merge(e); err != nil {
+			return nil, err
+		}
+	}
+	if l.environ {
+		out.init()
+		for _, kv := range os.Environ() {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				continue
+			}
+			// Unlike a file, the environment overrides even with an empty
+			// value, which is why this does not go through Merge.
+			env := &Row{key: NormalizeKey(k), value: v, origin: Origin{File: EnvironLayer}}
+			if r := out.Get(env.key); r != nil {
+				r.supersede(env)
+				r.SetValue(v)
+				continue
+			}
+			out.place(env)
+		}
+	}
+	return out, nil
+}
+
+// Save writes the layer called name back to the file of that name, leaving
+// every other layer alone. Nothing but the edits made to the layer shows up in
+// a diff of the file. It is an error wrapping [os.ErrNotExist] if there is no
+// such layer.
+func (l *Layers) Save(name string, opts ...Option) error {
+	e := l.Layer(name)
+	if e == nil {
+		return fmt.Errorf("envi: no layer named %q: %w", name, os.ErrNotExist)
+	}
+	return Save(e, name, opts...)
+}
@@ -0,0 +1,156 @@
+package envi_test
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// mustParse reads a document, failing the test if it does not parse.
+func mustParse(t *testing.T, s string) *envi.Env {
+	t.Helper()
+
+	e, err := envi.ParseString(s)
+	if err != nil {
+		t.Fatalf("parsing %q: %v", s, err)
+	}
+	return e
+}
+
+func TestLayersPrecedence(t *testing.T) {
+	t.Parallel()
+
+	l := &envi.Layers{}
+	l.Push("base", mustParse(t, "A=base\nB=base\nC=base\nE=\n")).
+		Push("local", mustParse(t, "B=local\nC=\nD=local\nE=\n"))
+
+	tests := []struct {
+		key, value, layer string
+		ok                bool
+	}{
+		{"A", "base", "base", true},
+		{"B", "local", "local", true},
+		// An empty value does not override, the same rule Load applies.
+		{"C", "base", "base", true},
+		{"D", "local", "local", true},
+		{"E", "", "base", true},
+		{"NOPE", "", "", false},
+	}
+	for _, tt := range tests {
+		value, ok := l.Lookup(tt.key)
+		if value != tt.value || ok != tt.ok {
+			t.Errorf("Lookup(%s) = %q, %v; want %q, %v", tt.key, value, ok, tt.value, tt.ok)
+		}
+		layer, ok := l.Which(tt.key)
+		if layer != tt.layer || ok != tt.ok {
+			t.Errorf("Which(%s) = %q, %v; want %q, %v", tt.key, layer, ok, tt.layer, tt.ok)
+		}
+	}
+
+	flat, err := l.Flatten()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		value, ok := flat.Lookup(tt.key)
+		if value != tt.value || ok != tt.ok {
+			t.Errorf("Flatten().Lookup(%s) = %q, %v; want %q, %v", tt.key, value, ok, tt.value, tt.ok)
+		}
+	}
+
+	// The flattened copy is independent of the layers it came from.
+	flat.Set("A", "changed")
+	if v, _ := l.Lookup("A"); v != "base" {
+		t.Errorf("editing the flattened copy changed a layer: A = %q", v)
+	}
+}
+
+// Not parallel: it sets the process environment.
+func TestLayersEnviron(t *testing.T) {
+	t.Setenv("ENVI_LAYERS_SET", "from-env")
+	t.Setenv("envi_layers_lower", "lower")
+	t.Setenv("ENVI_LAYERS_EMPTY", "")
+
+	l := (&envi.Layers{}).
+		Push(".env", mustParse(t, "ENVI_LAYERS_SET=file\nENVI_LAYERS_EMPTY=file\nENVI_LAYERS_LOWER=file\n"))
+
+	if v, _ := l.Lookup("ENVI_LAYERS_SET"); v != "file" {
+		t.Errorf("without the environment, value = %q, want the file's", v)
+	}
+
+	l.SetEnviron(true)
+	tests := []struct{ key, want string }{
+		{"ENVI_LAYERS_SET", "from-env"},
+		{"ENVI_LAYERS_LOWER", "lower"},
+		// The environment wins whenever it holds the variable at all.
+		{"ENVI_LAYERS_EMPTY", ""},
+	}
+	for _, tt := range tests {
+		if v, _ := l.Lookup(tt.key); v != tt.want {
+			t.Errorf("Lookup(%s) = %q, want %q", tt.key, v, tt.want)
+		}
+		if layer, _ := l.Which(tt.key); layer != envi.EnvironLayer {
+			t.Errorf("Which(%s) = %q, want %q", tt.key, layer, envi.EnvironLayer)
+		}
+	}
+
+	flat, err := l.Flatten()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := flat.Lookup("ENVI_LAYERS_SET"); v != "from-env" {
+		t.Errorf("Flatten: value = %q, want the environment's", v)
+	}
+	chain := flat.Explain("ENVI_LAYERS_SET")
+	if len(chain) != 2 || chain[1].File != envi.EnvironLayer {
+		t.Errorf("Explain = %v, want the file overridden by the environment", chain)
+	}
+}
+
+func TestLayersSaveWritesOnlyThatLayer(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	const baseText = "# base\nAPP_NAME=one\n"
+	const localText = "###   ---[ Local ]---   ###\nDB_HOST = 'localhost'  # quoted\nDB_PORT=5432\n"
+	base := writeEnvFile(t, dir, ".env", baseText)
+	local := writeEnvFile(t, dir, ".env.local", localText)
+
+	l, err := envi.LoadLayers(base, local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Names(); !slices.Equal(got, []string{base, local}) {
+		t.Errorf("Names = %v", got)
+	}
+	if l.Len() != 2 {
+		t.Errorf("Len = %d, want 2", l.Len())
+	}
+
+	l.Layer(local).Set("DB_PORT", "6543")
+	if err := l.Save(local); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "###   ---[ Local ]---   ###\nDB_HOST = 'localhost'  # quoted\nDB_PORT=6543\n"
+	if string(got) != want {
+		t.Errorf("saved layer = %q, want %q", got, want)
+	}
+	if got, _ := os.ReadFile(base); string(got) != baseText {
+		t.Errorf("the other layer changed: %q", got)
+	}
+
+	if err := l.Save("nope"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Save of an unknown layer = %v, want os.ErrNotExist", err)
+	}
+	if l.Layer("nope") != nil {
+		t.Error("Layer of an unknown name is not nil")
+	}
+}
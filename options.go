@@ -1,5 +1,7 @@
 package envi
 
+import "slices"
+
 // QuoteStyle selects how values are quoted when a document is written.
 type QuoteStyle int
 
@@ -80,11 +82,29 @@ type config struct {
 	quoting            QuoteStyle
 	order              Order
 
+	// redaction, when set, hides secret values in what is written and in what
+	// a check reports. See [WithRedaction].
+	redaction *Redaction
+
+	// signKey and verifyKey are the MAC keys of [WithSign] and [WithVerify].
+	signKey   []byte
+	verifyKey []byte
+
 	// disabledRules is a mask of the checks switched off with [WithoutRules].
 	// A mask rather than a set keeps config a plain value that can be copied
 	// into a Decoder without allocating or sharing anything.
 	disabledRules uint32
 
+	// disabledOther names the switched-off checks the mask has no bit for,
+	// the rules of a [Checker], and checkers are the ones from [WithCheckers].
+	// Both are appended to only through a clipped slice, so copies of a config
+	// never write into each other's.
+	disabledOther []Rule
+	checkers      []Checker
+
+	// knownRules are the rules from [WithKnownRules], appended to the same way.
+	knownRules []Rule
+
 	shadows       bool
 	comments      bool
 	commentedRows bool
@@ -181,15 +201,59 @@ func WithOrder(o Order) Option {
 	return optionFunc(func(c *config) { c.order = o })
 }
 
-// WithoutRules switches off the named checks. Checking only; a name this
-// package does not know switches nothing off, so a configuration written for a
-// later version stays usable.
+// WithoutRules switches off the named checks, the rules of a [Checker] as
+// much as this package's own. Checking only; a name no check reports switches
+// nothing off, so a configuration written for a later version stays usable.
 //
 //	env, rep, err := envi.CheckFile(".env", envi.WithoutRules(envi.RuleEmptyValue))
 func WithoutRules(rules ...Rule) Option {
 	var mask uint32
+	var other []Rule
 	for _, r := range rules {
-		mask |= r.bit()
+		if b := r.bit(); b != 0 {
+			mask |= b
+		} else {
+			other = append(other, r)
+		}
 	}
-	return optionFunc(func(c *config) { c.disabledRules |= mask })
+	return optionFunc(func(c *config) {
+		c.disabledRules |= mask
+		c.disabledOther = append(slices.Clip(c.disabledOther), other...)
+	})
+}
+
+// WithCheckers adds checks of the caller's own to those [Check] and
+// [Env.Check] run. Checking only; the checkers run after the built-in rules,
+// in the order given, and a nil one is skipped.
+//
+//	env, rep, err := envi.CheckFile(".env", envi.WithCheckers(httpsOnly))
+func WithCheckers(cs ...Checker) Option {
+	cs = slices.DeleteFunc(slices.Clone(cs), func(c Checker) bool { return c == nil })
+	return optionFunc(func(c *config) { c.checkers = append(slices.Clip(c.checkers), cs...) })
+}
+
+// WithKnownRules names rules that an [IgnoreMarker] may switch off besides the
+// built-in ones: those the caller's checkers report, or a schema's. Checking
+// only; a marker naming a rule neither built in nor named here is reported
+// under [RuleIgnoreUnknown].
+//
+//	env, rep, err := envi.CheckFile(".env",
+//		envi.WithCheckers(httpsOnly), envi.WithKnownRules("https-only"))
+func WithKnownRules(rules ...Rule) Option {
+	rules = slices.Clone(rules)
+	return optionFunc(func(c *config) { c.knownRules = append(slices.Clip(c.knownRules), rules...) })
+}
+
+// WithRedaction hides secret values, as p decides, in what is shown rather than
+// used. Encoding writes every secret value masked, which makes the output fit
+// for a log and unfit for loading back; checking keeps secret values out of
+// finding messages. A nil p means [DefaultRedaction].
+//
+//	envi.NewEncoder(os.Stderr, envi.WithRedaction(nil)).Encode(env)
+//
+// The policy is copied, so changing p afterwards does not change the option.
+func WithRedaction(p *Redaction) Option {
+	c := *p.orDefault()
+	c.Patterns = slices.Clone(c.Patterns)
+	return optionFunc(func(cfg *config) { cfg.redaction = &c })
 }
@@ -0,0 +1,117 @@
+package envi
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+)
+
+// ProfileMarker introduces the label that puts a value in a profile, written
+// as a word of the comment trailing the value:
+//
+//	# DB_URL=postgres://staging # @profile=staging
+//	DB_URL=postgres://prod # @profile=production
+//
+// A profile is the set of values labelled with it, across the whole file; see
+// [Env.ActivateProfile].
+const ProfileMarker = "@profile="
+
+// ErrNoProfile reports a profile that no value in the document is labelled
+// with, from [Env.ActivateProfile].
+var ErrNoProfile = errors.New("envi: no such profile")
+
+// profileOf returns the profile a trailing comment labels its value with, or
+// "" when it names none.
+func profileOf(comment string) string {
+	for w := range strings.FieldsSeq(comment) {
+		if name, ok := strings.CutPrefix(w, ProfileMarker); ok && name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// ShadowsFor iterates the row's shadows labelled with profile, in the order
+// they were seen. There is normally one.
+func (r *Row) ShadowsFor(profile string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, s := range r.shadows {
+			if profileOf(s.inline) == profile && !yield(s.value) {
+				return
+			}
+		}
+	}
+}
+
+// Profiles returns the names of the profiles the document's values are
+// labelled with, live values and shadows alike, sorted.
+func (e *Env) Profiles() []string {
+	var names []string
+	for r := range e.Rows() {
+		if name := profileOf(r.inline); name != "" && !r.commented {
+			names = append(names, name)
+		}
+		for _, s := range r.shadows {
+			if name := profileOf(s.inline); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// ActivateProfile switches every key that has a shadow labelled with the
+// profile to that value, with [Row.Promote], and returns what changed. The
+// values displaced become shadows, keeping their own labels, so activating the
+// profile they belong to switches back:
+//
+//	# DB_URL=postgres://staging # @profile=staging
+//	DB_URL=postgres://prod # @profile=production
+//
+// A key with no value in the profile keeps the one it has, and so does a
+// commented-out row, which configures nothing to switch. Every other row, and
+// each row's lines apart from the two a promotion rewrites, are left as they
+// are.
+//
+// A profile no value is labelled with is [ErrNoProfile], and a key with two
+// shadows in the profile is an error naming it; either way the document is
+// left unchanged.
+func (e *Env) ActivateProfile(name string) (*Delta, error) {
+	type switchTo struct {
+		row   *Row
+		value string
+	}
+	var todo []switchTo
+	known := false
+	for r := range e.Rows() {
+		if r.commented {
+			continue
+		}
+		live := profileOf(r.inline) == name
+		values := slices.Collect(r.ShadowsFor(name))
+		known = known || live || len(values) > 0
+		switch {
+		case live || len(values) == 0:
+		case len(values) > 1:
+			return nil, fmt.Errorf("envi: profile %s: %s has %d values in it", name, r.key, len(values))
+		default:
+			todo = append(todo, switchTo{r, values[0]})
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("%w: %s", ErrNoProfile, name)
+	}
+
+	d := &Delta{}
+	for _, t := range todo {
+		old := t.row.value
+		t.row.Promote(t.value)
+		if old != t.row.value {
+			d.changes = append(d.changes, Change{Kind: ChangeChanged, Key: t.row.key, Old: old, New: t.row.value})
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,79 @@
+package envi_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+const profileSrc = "A=1\n\n# the database\n#DB_URL='postgres://staging'  # @profile=staging\n" +
+	"DB_URL=\"postgres://prod\" # @profile=production\n\n# LOG=debug # noisy @profile=staging\nLOG=info\nPORT=80\n"
+
+func TestActivateProfile(t *testing.T) {
+	t.Parallel()
+
+	e := mustParse(t, profileSrc)
+	d, err := e.ActivateProfile("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.String(), "~ DB_URL: \"postgres://prod\" -> \"postgres://staging\"\n~ LOG: \"info\" -> \"debug\"\n"; got != want {
+		t.Errorf("delta = %q, want %q", got, want)
+	}
+	// Each switched key changes in two lines, and nothing else moves.
+	want := "A=1\n\n# the database\n# DB_URL=postgres://prod # @profile=production\n" +
+		"DB_URL=postgres://staging # @profile=staging\n\n# LOG=info\nLOG=debug # noisy @profile=staging\nPORT=80\n"
+	if got := e.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// The displaced value kept its label, so its profile switches back.
+	if d, err = e.ActivateProfile("production"); err != nil || d.Len() != 1 {
+		t.Fatalf("production: %v, %v", d, err)
+	}
+	if got, _ := e.Lookup("DB_URL"); got != "postgres://prod" {
+		t.Errorf("DB_URL = %q after switching back", got)
+	}
+
+	// Activating the profile in force changes nothing.
+	if d, err = e.ActivateProfile("production"); err != nil || !d.Empty() {
+		t.Errorf("again: %v, %v", d, err)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	t.Parallel()
+
+	e := mustParse(t, profileSrc+"# NAME=x # @profile=qa\n")
+	if got, want := e.Profiles(), []string{"production", "staging"}; !slices.Equal(got, want) {
+		t.Errorf("Profiles = %v, want %v: a commented-out row is in no profile", got, want)
+	}
+	if got := slices.Collect(e.Get("LOG").ShadowsFor("staging")); !slices.Equal(got, []string{"debug"}) {
+		t.Errorf("ShadowsFor(staging) = %v", got)
+	}
+	if got := slices.Collect(e.Get("LOG").ShadowsFor("production")); len(got) != 0 {
+		t.Errorf("ShadowsFor(production) = %v", got)
+	}
+}
+
+func TestActivateProfileErrors(t *testing.T) {
+	t.Parallel()
+
+	e := mustParse(t, profileSrc)
+	if _, err := e.ActivateProfile("qa"); !errors.Is(err, envi.ErrNoProfile) {
+		t.Errorf("unknown profile: err = %v", err)
+	}
+
+	src := "A=2\n# K=a # @profile=x\n# K=b # @profile=x\nK=c\n# A=1 # @profile=x\n"
+	e = mustParse(t, src)
+	before := e.String()
+	if _, err := e.ActivateProfile("x"); err == nil || !strings.Contains(err.Error(), "K has 2 values") {
+		t.Errorf("two values: err = %v", err)
+	}
+	if e.String() != before {
+		t.Error("a failed activation changed the document")
+	}
+}
@@ -0,0 +1,111 @@
+package envi
+
+import (
+	"slices"
+	"strconv"
+)
+
+// An Origin is where a value was stated: a file, and a line in it.
+//
+// A document read through [Load] or [LoadWith] knows both. One read through
+// [Parse] and its variants knows only the line, since a reader has no name, and
+// a row built in memory has the zero Origin.
+type Origin struct {
+	// File is the path the value was read from, as it was given to [Load].
+	File string `json:"file,omitempty"`
+
+	// Line is the 1-based line of the assignment, 0 when not known.
+	Line int `json:"line,omitempty"`
+}
+
+// IsZero reports whether nothing is known about where the value came from.
+func (o Origin) IsZero() bool { return o.File == "" && o.Line == 0 }
+
+// String renders the origin the way compilers do, "path:line", so that an
+// editor or a CI log turns it into a link. A part that is not known is left
+// out, and the zero Origin renders as "(memory)".
+func (o Origin) String() string {
+	switch {
+	case o.File != "" && o.Line > 0:
+		return o.File + ":" + strconv.Itoa(o.Line)
+	case o.File != "":
+		return o.File
+	case o.Line > 0:
+		return "line " + strconv.Itoa(o.Line)
+	default:
+		return "(memory)"
+	}
+}
+
+// A Definition is one statement of a key: the value it gave and where.
+type Definition struct {
+	Origin
+
+	// Value is the value the statement gave, with quoting resolved.
+	Value string `json:"value"`
+}
+
+// String renders the definition as its origin followed by the quoted value:
+//
+//	.env.local:3: "db.internal"
+func (d Definition) String() string {
+	return d.Origin.String() + ": " + strconv.Quote(d.Value)
+}
+
+// Origin reports where the row's value was stated. When several files define
+// the key, it is the one that won.
+func (r *Row) Origin() Origin { return r.origin }
+
+// Overrode returns the earlier statements of the key that the row's value
+// displaced, oldest first — the files a merge let this one override, and an
+// earlier line of the same file that gave the key a value twice.
+//
+// A later statement that left the value empty displaced nothing and is not
+// listed; see [Env.Merge] for why an empty value does not override.
+func (r *Row) Overrode() []Definition { return slices.Clone(r.overrode) }
+
+// Explain returns every statement of key that went into the document, in the
+// order they were read. The last one is the value the document holds; the ones
+// before it are what it overrode.
+//
+// It is the question to ask when a value is not the one expected:
+//
+//	env, _ := envi.Load(".env", ".env.local")
+//	for _, d := range env.Explain("DB_HOST") {
+//	    fmt.Println(d.Origin, d.Value)
+//	}
+//
+// It returns nil when the document has no row under key.
+func (e *Env) Explain(key string) []Definition {
+	r := e.Get(key)
+	if r == nil {
+		return nil
+	}
+	out := make([]Definition, 0, len(r.overrode)+1)
+	out = append(out, r.overrode...)
+	return append(out, r.definition())
+}
+
+// definition is the row's current statement.
+func (r *Row) definition() Definition {
+	return Definition{Origin: r.origin, Value: r.value}
+}
+
+// supersede records that next restates the key after r: what r held so far
+// joins the overridden statements, followed by any next had already overridden
+// on its own account, and next's origin becomes r's.
+func (r *Row) supersede(next *Row) {
+	r.overrode = append(r.overrode, r.definition())
+	r.overrode = append(r.overrode, next.overrode...)
+	r.origin = next.origin
+}
+
+// setFile names the file every row of a freshly read document came from.
+func (e *Env) setFile(path string) {
+	for r := range e.Rows() {
+		r.origin.File = path
+		for i := range r.overrode {
+			r.overrode[i].File = path
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package envi_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// writeEnvFile puts content in a file in dir and returns its path.
+func writeEnvFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExplainAcrossFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	base := writeEnvFile(t, dir, ".env", "APP_NAME=one\nDB_HOST=localhost\n")
+	local := writeEnvFile(t, dir, ".env.local", "# local\n\nDB_HOST=db.local\n")
+	prod := writeEnvFile(t, dir, ".env.prod", "DB_HOST=db.prod\nAPP_NAME=\n")
+
+	e, err := envi.Load(base, local, prod)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []envi.Definition{
+		{Origin: envi.Origin{File: base, Line: 2}, Value: "localhost"},
+		{Origin: envi.Origin{File: local, Line: 3}, Value: "db.local"},
+		{Origin: envi.Origin{File: prod, Line: 1}, Value: "db.prod"},
+	}
+	if got := e.Explain("db-host"); !slices.Equal(got, want) {
+		t.Errorf("Explain = %v, want %v", got, want)
+	}
+
+	r := e.Get("DB_HOST")
+	if r.Origin() != want[2].Origin {
+		t.Errorf("Origin = %v, want %v", r.Origin(), want[2].Origin)
+	}
+	if got := r.Overrode(); !slices.Equal(got, want[:2]) {
+		t.Errorf("Overrode = %v, want %v", got, want[:2])
+	}
+
+	// An empty later value does not override, so it is not a statement that
+	// went into the result either.
+	wantName := []envi.Definition{{Origin: envi.Origin{File: base, Line: 1}, Value: "one"}}
+	if got := e.Explain("APP_NAME"); !slices.Equal(got, wantName) {
+		t.Errorf("Explain(APP_NAME) = %v, want %v", got, wantName)
+	}
+}
+
+func TestExplainWithinOneDocument(t *testing.T) {
+	t.Parallel()
+
+	e, err := envi.ParseString("K=first\n# K=alt\nK=second\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []envi.Definition{
+		{Origin: envi.Origin{Line: 1}, Value: "first"},
+		{Origin: envi.Origin{Line: 3}, Value: "second"},
+	}
+	if got := e.Explain("K"); !slices.Equal(got, want) {
+		t.Errorf("Explain = %v, want %v", got, want)
+	}
+}
+
+func TestExplainMissingKeyAndMemoryRows(t *testing.T) {
+	t.Parallel()
+
+	e := envi.New()
+	if got := e.Explain("NOPE"); got != nil {
+		t.Errorf("Explain of an absent key = %v, want nil", got)
+	}
+
+	e.Set("K", "v")
+	got := e.Explain("K")
+	if len(got) != 1 || !got[0].Origin.IsZero() || got[0].Value != "v" {
+		t.Errorf("Explain = %v, want one definition with the zero origin", got)
+	}
+}
+
+func TestOriginString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   envi.Origin
+		want string
+	}{
+		{envi.Origin{File: ".env", Line: 4}, ".env:4"},
+		{envi.Origin{File: ".env"}, ".env"},
+		{envi.Origin{Line: 4}, "line 4"},
+		{envi.Origin{}, "(memory)"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("%#v.String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	d := envi.Definition{Origin: envi.Origin{File: ".env", Line: 2}, Value: "a b"}
+	if got, want := d.String(), `.env:2: "a b"`; got != want {
+		t.Errorf("Definition.String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,197 @@
+package envi
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// DefaultMask is what a secret value is shown as when a [Redaction] names no
+// mask of its own.
+const DefaultMask = "******"
+
+// SecretMarker is the word that marks a row secret under [DefaultRedaction],
+// written in the comment above the row or trailing it:
+//
+//	# @secret
+//	SIGNING_KEY=9f86d081884c7d65
+//	UPSTREAM_URL=https://user:pw@example.com # @secret
+const SecretMarker = "@secret"
+
+// A Redaction decides which values are secret, and hides them wherever a
+// document is shown rather than used: [Env.RedactedView], an [Encoder] or a
+// [Check] given [WithRedaction], and [Delta.Redact].
+//
+// A nil *Redaction stands for [DefaultRedaction] wherever one is accepted.
+type Redaction struct {
+	// Patterns are matched against normalised keys with the syntax of
+	// [path.Match], so "*_TOKEN" covers GITHUB_TOKEN. They are compared in
+	// upper case, the form every key takes. A malformed pattern matches
+	// nothing.
+	Patterns []string
+
+	// Marker is a word which, standing on its own in a row's comment or
+	// trailing comment, makes the row secret whatever its key. Empty means
+	// comments are not consulted.
+	Marker string
+
+	// Mask replaces a secret value. Empty means [DefaultMask].
+	Mask string
+}
+
+// defaultRedaction backs [DefaultRedaction] and every nil *Redaction.
+var defaultRedaction = Redaction{
+	Patterns: []string{"*_PASSWORD", "*_SECRET", "*_TOKEN"},
+	Marker:   SecretMarker,
+}
+
+// DefaultRedaction returns the policy used when none is given: keys ending in
+// _PASSWORD, _SECRET or _TOKEN, and rows whose comment says [SecretMarker],
+// shown as [DefaultMask]. It is a fresh copy, free to extend:
+//
+//	p := envi.DefaultRedaction()
+//	p.Patterns = append(p.Patterns, "*_DSN")
+func DefaultRedaction() *Redaction {
+	p := defaultRedaction
+	p.Patterns = slices.Clone(p.Patterns)
+	return &p
+}
+
+// orDefault resolves nil to the default policy.
+func (p *Redaction) orDefault() *Redaction {
+	if p == nil {
+		return &defaultRedaction
+	}
+	return p
+}
+
+// MatchKey reports whether key is secret by name alone.
+func (p *Redaction) MatchKey(key string) bool {
+	p = p.orDefault()
+	k := NormalizeKey(key)
+	for _, pat := range p.Patterns {
+		if ok, _ := path.Match(strings.ToUpper(pat), k); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Secret reports whether the row's value is secret: its key matches, or one of
+// its comments carries the marker.
+func (p *Redaction) Secret(r *Row) bool {
+	if r == nil {
+		return false
+	}
+	p = p.orDefault()
+	return p.MatchKey(r.key) || p.marked(r.comment) || p.marked(r.inline)
+}
+
+// marked reports whether the marker stands as a word of comment.
+func (p *Redaction) marked(comment string) bool {
+	if p.Marker == "" || comment == "" {
+		return false
+	}
+	return slices.Contains(strings.Fields(comment), p.Marker)
+}
+
+// mask returns what a secret value is shown as.
+func (p *Redaction) mask() string {
+	if p.Mask == "" {
+		return DefaultMask
+	}
+	return p.Mask
+}
+
+// hide returns value as it may be shown. An empty value is left alone: it
+// gives nothing away, and that a secret is unset is worth seeing.
+func (p *Redaction) hide(value string) string {
+	if value == "" {
+		return value
+	}
+	return p.mask()
+}
+
+// secretIn reports whether key is secret in any of docs, by name or by the
+// comments of the row holding it.
+func (p *Redaction) secretIn(key string, docs []*Env) bool {
+	if p.MatchKey(key) {
+		return true
+	}
+	for _, e := range docs {
+		if e != nil && p.Secret(e.Get(key)) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactedView returns a copy of the document with every secret value masked,
+// for logging the whole configuration safely:
+//
+//	log.Printf("config:\n%s", env.RedactedView(nil))
+//
+// Shadows and overridden values of a secret row are masked too, since they are
+// usually an older secret. A masked row is written from the model rather than
+// reproduced, so its original quoting is not shown either. A nil policy means
+// [DefaultRedaction]. The document itself is left alone.
+func (e *Env) RedactedView(p *Redaction) *Env {
+	p = p.orDefault()
+	e.compact()
+	out := &Env{
+		items:   make([]Item, 0, len(e.items)),
+		eol:     e.eol,
+		trailer: slices.Clone(e.trailer),
+	}
+	for _, it := range e.items {
+		switch v := it.(type) {
+		case *Row:
+			c := v.clone()
+			p.redactRow(c)
+			out.items = append(out.items, c)
+		case *Block:
+			c := v.clone()
+			for _, r := range c.rows {
+				p.redactRow(r)
+			}
+			out.items = append(out.items, c)
+		}
+	}
+	out.reindex()
+	return out
+}
+
+// redactRow masks a secret row in place.
+func (p *Redaction) redactRow(r *Row) {
+	if !p.Secret(r) {
+		return
+	}
+	r.SetValue(p.hide(r.value))
+	for i := range r.shadows {
+		r.shadows[i].value = p.hide(r.shadows[i].value)
+	}
+	for i := range r.overrode {
+		r.overrode[i].Value = p.hide(r.overrode[i].Value)
+	}
+}
+
+// Redact returns a copy of the delta with the values of secret keys masked, so
+// that a comparison of production files can be pasted into a review. A key is
+// secret when its name matches, or when the row holding it in any of docs —
+// normally the two documents compared — is marked secret in a comment. A nil
+// policy means [DefaultRedaction].
+//
+//	fmt.Print(old.Diff(next).Redact(nil, old, next))
+//
+// An empty value stays visible; a change of a secret still shows as a change.
+func (d *Delta) Redact(p *Redaction, docs ...*Env) *Delta {
+	p = p.orDefault()
+	out := &Delta{changes: slices.Clone(d.changes)}
+	for i := range out.changes {
+		c := &out.changes[i]
+		if p.secretIn(c.Key, docs) {
+			c.Old, c.New = p.hide(c.Old), p.hide(c.New)
+		}
+	}
+	return out
+}
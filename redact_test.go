@@ -0,0 +1,160 @@
+package envi_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+const secretsDoc = "APP_NAME=shop\n" +
+	"# DB_PASSWORD=old-pw\nDB_PASSWORD=hunter2\n" +
+	"# @secret\nSIGNING_KEY=k3y\n" +
+	"UPSTREAM=https://u:p@host # @secret\n" +
+	"GITHUB_TOKEN=\n"
+
+func TestRedactionSecret(t *testing.T) {
+	t.Parallel()
+
+	e := mustParse(t, secretsDoc)
+	p := envi.DefaultRedaction()
+	for key, want := range map[string]bool{
+		"APP_NAME":     false,
+		"DB_PASSWORD":  true,
+		"SIGNING_KEY":  true,
+		"UPSTREAM":     true,
+		"GITHUB_TOKEN": true,
+	} {
+		if got := p.Secret(e.Get(key)); got != want {
+			t.Errorf("Secret(%s) = %v, want %v", key, got, want)
+		}
+	}
+	if !(*envi.Redaction)(nil).MatchKey("github-token") {
+		t.Error("a nil policy is not the default one")
+	}
+	if (&envi.Redaction{Patterns: []string{"["}}).MatchKey("X") {
+		t.Error("a malformed pattern matched")
+	}
+	if (&envi.Redaction{}).Secret(e.Get("SIGNING_KEY")) {
+		t.Error("a policy with no marker consulted comments")
+	}
+}
+
+func TestRedactedView(t *testing.T) {
+	t.Parallel()
+
+	e := mustParse(t, secretsDoc)
+	got := e.RedactedView(nil).String()
+	want := "APP_NAME=shop\n" +
+		"# DB_PASSWORD=******\nDB_PASSWORD=******\n" +
+		"# @secret\nSIGNING_KEY=******\n" +
+		"UPSTREAM=****** # @secret\n" +
+		"GITHUB_TOKEN=\n"
+	if got != want {
+		t.Errorf("view =\n%s\nwant\n%s", got, want)
+	}
+	if e.String() != secretsDoc {
+		t.Errorf("the document itself changed:\n%s", e)
+	}
+	if v, _ := e.RedactedView(&envi.Redaction{Patterns: []string{"APP_*"}, Mask: "x"}).Lookup("APP_NAME"); v != "x" {
+		t.Errorf("custom policy: APP_NAME = %q", v)
+	}
+}
+
+func TestRedactedViewMasksOverriddenValues(t *testing.T) {
+	t.Parallel()
+
+	e := mustParse(t, "API_TOKEN=first\nAPI_TOKEN=second\n")
+	for _, d := range e.RedactedView(nil).Explain("API_TOKEN") {
+		if d.Value != envi.DefaultMask {
+			t.Errorf("definition %s shows the secret", d)
+		}
+	}
+}
+
+func TestWithRedactionEncodes(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	if err := envi.NewEncoder(&b, envi.WithRedaction(nil)).Encode(mustParse(t, "A=1\nX_SECRET=s\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "A=1\nX_SECRET=******\n"; got != want {
+		t.Errorf("encoded %q, want %q", got, want)
+	}
+}
+
+func TestWithRedactionCopiesThePolicy(t *testing.T) {
+	t.Parallel()
+
+	p := &envi.Redaction{Patterns: []string{"A"}}
+	opt := envi.WithRedaction(p)
+	p.Patterns[0] = "B"
+
+	var b strings.Builder
+	if err := envi.NewEncoder(&b, opt).Encode(mustParse(t, "A=1\nB=2\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "A=******\nB=2\n"; got != want {
+		t.Errorf("encoded %q, want %q", got, want)
+	}
+}
+
+func TestWithRedactionChecks(t *testing.T) {
+	t.Parallel()
+
+	const src = "PLAIN=a$b\nDB_PASSWORD=a$b\n"
+	_, rep, err := envi.CheckString(src, envi.WithRedaction(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []string
+	for p := range rep.All() {
+		msgs = append(msgs, p.Key+": "+p.Msg)
+	}
+	want := []string{
+		"PLAIN: bare value holds '$'",
+		"DB_PASSWORD: bare value holds a character the shell interprets",
+	}
+	if strings.Join(msgs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("messages = %q, want %q", msgs, want)
+	}
+
+	// A checker masks the value it quotes, and only that: a secret as short
+	// as "1" leaves the rest of the message alone.
+	odd := envi.CheckerFunc(func(r *envi.Row, line int, _ *envi.Env, rep *envi.Report) {
+		msg := fmt.Sprintf("value %s on line %d is odd", rep.Redact(r.Key(), r.Value()), line)
+		rep.Add(envi.Problem{Rule: "odd", Line: line, Key: r.Key(), Msg: msg})
+	})
+	_, rep, _ = envi.CheckString("N=1 # @secret\nM=1\n", envi.WithCheckers(odd), envi.WithRedaction(nil))
+	if got, want := rep.String(), "1: error: odd: value "+envi.DefaultMask+" on line 1 is odd (N)\n"+
+		"2: error: odd: value 1 on line 2 is odd (M)\n"; got != want {
+		t.Errorf("got\n%swant\n%s", got, want)
+	}
+}
+
+func TestDeltaRedact(t *testing.T) {
+	t.Parallel()
+
+	old := mustParse(t, "A=1\nDB_PASSWORD=x\n# @secret\nK=old\nT_TOKEN=\n")
+	next := mustParse(t, "A=2\nDB_PASSWORD=y\nK=new\nT_TOKEN=t\nN_SECRET=n\n")
+	d := old.Diff(next)
+
+	got := d.Redact(nil, old, next).String()
+	want := "~ A: \"1\" -> \"2\"\n" +
+		"~ DB_PASSWORD: \"******\" -> \"******\"\n" +
+		"~ K: \"******\" -> \"******\"\n" +
+		"~ T_TOKEN: \"\" -> \"******\"\n" +
+		"+ N_SECRET=\"******\"\n"
+	if got != want {
+		t.Errorf("redacted =\n%s\nwant\n%s", got, want)
+	}
+	if !strings.Contains(d.String(), `"x" -> "y"`) {
+		t.Errorf("Redact changed the delta it was called on:\n%s", d)
+	}
+	// Without the documents the comment marking K cannot be seen.
+	if !strings.Contains(d.Redact(nil).String(), `K: "old" -> "new"`) {
+		t.Errorf("K masked without the document that marks it:\n%s", d.Redact(nil))
+	}
+}
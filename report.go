@@ -2,8 +2,11 @@ package envi
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"io"
+	"net/url"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
@@ -65,3 +68,233 @@ func (r *Report) String() string {
 	_ = r.Text(&b)
 	return b.String()
 }
+
+// A FileReport is the report of a check together with the file it is about,
+// for the formats that name files: [WriteSARIF] and [WriteCheckstyle].
+type FileReport struct {
+	File   string
+	Report *Report
+
+	// Rules describes the rules beyond this package's that the report's
+	// findings may be under — those of a [Checker] or a schema — for the
+	// formats that carry rule metadata.
+	Rules []RuleInfo
+}
+
+// SARIF writes the report as a SARIF 2.1.0 log of one run, the format code
+// scanning dashboards take, with every finding located in file. See
+// [WriteSARIF].
+func (r *Report) SARIF(w io.Writer, file string) error {
+	return WriteSARIF(w, FileReport{File: file, Report: r})
+}
+
+// Checkstyle writes the report in the XML format of Checkstyle, which most CI
+// servers annotate a build with, every finding located in file. See
+// [WriteCheckstyle].
+func (r *Report) Checkstyle(w io.Writer, file string) error {
+	return WriteCheckstyle(w, FileReport{File: file, Report: r})
+}
+
+// sarifSchema is where the SARIF 2.1.0 schema lives, named at the top of a log
+// so that an editor can validate it.
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// The parts of a SARIF log this package writes. The format has many more.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string        `json:"id"`
+		ShortDescription *sarifMessage `json:"shortDescription,omitempty"`
+		DefaultConfig    *sarifConfig  `json:"defaultConfiguration,omitempty"`
+	}
+	sarifConfig struct {
+		Level string `json:"level"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID     string            `json:"ruleId"`
+		RuleIndex  int               `json:"ruleIndex"`
+		Level      string            `json:"level"`
+		Message    sarifMessage      `json:"message"`
+		Locations  []sarifLocation   `json:"locations"`
+		Properties map[string]string `json:"properties,omitempty"`
+	}
+	sarifLocation struct {
+		Physical sarifPhysical `json:"physicalLocation"`
+	}
+	sarifPhysical struct {
+		Artifact sarifArtifact `json:"artifactLocation"`
+		Region   *sarifRegion  `json:"region,omitempty"`
+	}
+	sarifArtifact struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+)
+
+// WriteSARIF writes the reports of any number of files as one SARIF 2.1.0 log
+// with a single run. The run's rules describe every rule of this package, then
+// those the reports describe in their Rules, and after them any other a finding
+// was recorded under, with no description, so that each result can point at its
+// rule's metadata.
+//
+// A finding with no line is located in its file alone, and the key a finding
+// concerns travels as its "key" property. A file's path is written as a URI:
+// relative, with forward slashes, or a file URI when the path is absolute.
+func WriteSARIF(w io.Writer, reports ...FileReport) error {
+	rules := make([]sarifRule, 0, len(builtinRules))
+	index := make(map[Rule]int, len(builtinRules))
+	describe := func(b RuleInfo) {
+		if _, ok := index[b.Rule]; ok {
+			return
+		}
+		index[b.Rule] = len(rules)
+		rules = append(rules, sarifRule{
+			ID:               string(b.Rule),
+			ShortDescription: &sarifMessage{Text: b.Summary},
+			DefaultConfig:    &sarifConfig{Level: b.Severity.String()},
+		})
+	}
+	for _, b := range builtinRules {
+		describe(b)
+	}
+	for _, fr := range reports {
+		for _, b := range fr.Rules {
+			describe(b)
+		}
+	}
+
+	results := []sarifResult{}
+	for _, fr := range reports {
+		for _, p := range fr.Report.problems {
+			i, ok := index[p.Rule]
+			if !ok {
+				i = len(rules)
+				index[p.Rule] = i
+				rules = append(rules, sarifRule{ID: string(p.Rule)})
+			}
+			res := sarifResult{
+				RuleID:    string(p.Rule),
+				RuleIndex: i,
+				Level:     p.Severity.String(),
+				Message:   sarifMessage{Text: p.Msg},
+				Locations: []sarifLocation{{Physical: sarifPhysical{Artifact: sarifArtifact{URI: sarifURI(fr.File)}}}},
+			}
+			if p.Line > 0 {
+				res.Locations[0].Physical.Region = &sarifRegion{StartLine: p.Line, StartColumn: p.Col}
+			}
+			if p.Key != "" {
+				res.Properties = map[string]string{"key": p.Key}
+			}
+			results = append(results, res)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "envi",
+				InformationURI: "https://github.com/efureev/envi",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	})
+}
+
+// sarifURI returns path as the URI a SARIF artifact location takes. A relative
+// path stays relative, resolved against wherever the log is read; an absolute
+// one, which as it stands would read as a path on a host or a URI scheme such
+// as "C:", becomes a file URI.
+func sarifURI(path string) string {
+	p := filepath.ToSlash(path)
+	if !filepath.IsAbs(path) {
+		return (&url.URL{Path: p}).String()
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// The parts of a Checkstyle report.
+type (
+	checkstyleReport struct {
+		XMLName xml.Name         `xml:"checkstyle"`
+		Version string           `xml:"version,attr"`
+		Files   []checkstyleFile `xml:"file"`
+	}
+	checkstyleFile struct {
+		Name   string            `xml:"name,attr"`
+		Errors []checkstyleError `xml:"error"`
+	}
+	checkstyleError struct {
+		Line     int    `xml:"line,attr"`
+		Column   int    `xml:"column,attr,omitempty"`
+		Severity string `xml:"severity,attr"`
+		Message  string `xml:"message,attr"`
+		Source   string `xml:"source,attr"`
+	}
+)
+
+// WriteCheckstyle writes the reports of any number of files as one Checkstyle
+// XML report. Every file is listed, a clean one with no errors, which is how
+// the format says a file was checked and passed. A finding's source is its rule
+// under the prefix "envi.", and a finding with no line is placed on line 0.
+func WriteCheckstyle(w io.Writer, reports ...FileReport) error {
+	doc := checkstyleReport{Version: "4.3"}
+	for _, fr := range reports {
+		f := checkstyleFile{Name: fr.File}
+		for _, p := range fr.Report.problems {
+			msg := p.Msg
+			if p.Key != "" {
+				msg += " (" + p.Key + ")"
+			}
+			f.Errors = append(f.Errors, checkstyleError{
+				Line:     p.Line,
+				Column:   p.Col,
+				Severity: p.Severity.String(),
+				Message:  msg,
+				Source:   "envi." + string(p.Rule),
+			})
+		}
+		doc.Files = append(doc.Files, f)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
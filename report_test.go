@@ -3,6 +3,9 @@ package envi_test
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
 	"slices"
 	"strings"
 	"testing"
@@ -252,3 +255,180 @@ func TestReportTextReportsTerminatorWriteErrors(t *testing.T) {
 		t.Errorf("Text = %v, want %v", got, want)
 	}
 }
+
+func TestWriteSARIF(t *testing.T) {
+	t.Parallel()
+
+	_, a, _ := envi.CheckString("app-name=x\nK=\"a\n")
+	_, b, _ := envi.CheckString("API_URL=http://x\n", envi.WithCheckers(httpsOnly))
+
+	var out strings.Builder
+	if err := envi.WriteSARIF(&out, envi.FileReport{File: "a.env", Report: a}, envi.FileReport{File: "b.env", Report: b}); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID               string `json:"id"`
+						ShortDescription struct {
+							Text string `json:"text"`
+						} `json:"shortDescription"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Locations []struct {
+					Physical struct {
+						Artifact struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &log); err != nil {
+		t.Fatalf("the log does not parse: %v\n%s", err, out.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("version %q, %d runs: want one 2.1.0 run", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+
+	// Every built-in rule is described, and a custom one is listed after.
+	var ids []string
+	for _, r := range run.Tool.Driver.Rules {
+		ids = append(ids, r.ID)
+		if r.ID != "url-https" && r.ShortDescription.Text == "" {
+			t.Errorf("rule %s has no description", r.ID)
+		}
+	}
+	want := []string{"syntax", "duplicate-key", "key-invalid", "key-not-canonical", "empty-value", "unquoted-value", "ignore-unknown", "url-https"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("rules = %v, want %v", ids, want)
+	}
+
+	var got []string
+	for _, r := range run.Results {
+		loc := r.Locations[0].Physical
+		if ids[r.RuleIndex] != r.RuleID {
+			t.Errorf("result %s points at rule %d", r.RuleID, r.RuleIndex)
+		}
+		got = append(got, fmt.Sprintf("%s:%d:%d %s %s", loc.Artifact.URI, loc.Region.StartLine, loc.Region.StartColumn, r.Level, r.RuleID))
+	}
+	wantResults := []string{
+		"a.env:1:0 warning key-not-canonical",
+		"a.env:2:3 error syntax",
+		"b.env:1:0 error url-https",
+	}
+	if !slices.Equal(got, wantResults) {
+		t.Errorf("results =\n%v\nwant\n%v", got, wantResults)
+	}
+}
+
+func TestWriteSARIFLocationsAndRuleInfo(t *testing.T) {
+	t.Parallel()
+
+	_, rep, _ := envi.CheckString("API_URL=http://x\n", envi.WithCheckers(httpsOnly))
+	abs, err := filepath.Abs("conf dir/.env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := []envi.RuleInfo{{Rule: "url-https", Severity: envi.SeverityError, Summary: "A URL that is not https."}}
+
+	var out strings.Builder
+	err = envi.WriteSARIF(&out,
+		envi.FileReport{File: abs, Report: rep, Rules: info},
+		envi.FileReport{File: filepath.Join("conf dir", ".env"), Report: rep, Rules: info})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID               string `json:"id"`
+						ShortDescription struct {
+							Text string `json:"text"`
+						} `json:"shortDescription"`
+						DefaultConfig struct {
+							Level string `json:"level"`
+						} `json:"defaultConfiguration"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				Locations []struct {
+					Physical struct {
+						Artifact struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &log); err != nil {
+		t.Fatalf("the log does not parse: %v\n%s", err, out.String())
+	}
+	run := log.Runs[0]
+
+	rules := run.Tool.Driver.Rules
+	if last := rules[len(rules)-1]; last.ID != "url-https" || last.ShortDescription.Text != "A URL that is not https." || last.DefaultConfig.Level != "error" {
+		t.Errorf("url-https is described as %+v", last)
+	}
+	if len(rules) != 8 {
+		t.Errorf("%d rules, want the built-in ones and url-https once", len(rules))
+	}
+
+	var uris []string
+	for _, r := range run.Results {
+		uris = append(uris, r.Locations[0].Physical.Artifact.URI)
+	}
+	u, err := url.Parse(uris[0])
+	if err != nil || u.Scheme != "file" || u.Host != "" || !strings.HasSuffix(u.Path, "/conf dir/.env") {
+		t.Errorf("absolute path as %q, want a file URI", uris[0])
+	}
+	if uris[1] != "conf%20dir/.env" {
+		t.Errorf("relative path as %q, want conf%%20dir/.env", uris[1])
+	}
+}
+
+func TestReportCheckstyle(t *testing.T) {
+	t.Parallel()
+
+	_, rep, _ := envi.CheckString("app-name=x\nEMPTY=\n")
+	var out strings.Builder
+	if err := rep.Checkstyle(&out, ".env"); err != nil {
+		t.Fatal(err)
+	}
+	const want = `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3">
+  <file name=".env">
+    <error line="1" severity="warning" message="key is written as &#34;app-name&#34; (APP_NAME)" source="envi.key-not-canonical"></error>
+    <error line="2" severity="warning" message="value is empty (EMPTY)" source="envi.empty-value"></error>
+  </file>
+</checkstyle>
+`
+	if got := out.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// A clean file is listed with no errors: checked, and passed.
+	out.Reset()
+	var clean envi.Report
+	if err := clean.Checkstyle(&out, ".env"); err != nil || !strings.Contains(out.String(), `<file name=".env"></file>`) {
+		t.Errorf("clean report: %v\n%s", err, out.String())
+	}
+}
@@ -3,6 +3,9 @@ package envi
 import (
 	"iter"
 	"slices"
+	"strings"
+
+	"github.com/efureev/envi/v2/internal/rowedit"
 )
 
 // A Row is one KEY=value entry of a document, together with the comment above
@@ -35,6 +38,11 @@ type Row struct {
 	rawLine   string
 	rawPrefix []string
 
+	// rawBelow is the verbatim lines below a commented row stating its
+	// shadows: the later commented statements of the key it folded in. It is
+	// only consulted while the row is parsed.
+	rawBelow []string
+
 	// parsed marks a row that came from input and has not changed since, so
 	// rawPrefix is authoritative for the lines above it. rawLine may still be
 	// empty for such a row: an assignment written in canonical form is
@@ -42,8 +50,13 @@ type Row struct {
 	// cost an allocation per line for nothing.
 	parsed bool
 
-	shadows   []string
+	shadows   []shadow
 	commented bool
+
+	// origin is where the value was stated, and overrode every earlier
+	// statement of the key it displaced, oldest first. See [Env.Explain].
+	origin   Origin
+	overrode []Definition
 }
 
 // NewRow returns a row with the given key and value. The key is normalised (see
@@ -68,10 +81,23 @@ func (r *Row) SetValue(v string) *Row {
 	return r
 }
 
+// replaceValue replaces the value the way an edit in place does: the
+// assignment is written afresh, and the lines above it still stand, so the
+// change shows up in a diff of the file as that one line.
+func (r *Row) replaceValue(v string) {
+	r.value = v
+	r.dropLine()
+}
+
+func init() {
+	rowedit.ReplaceValue = func(row any, v string) { row.(*Row).replaceValue(v) }
+}
+
 // dropRaw forgets the verbatim rendering after the row's content changes.
 func (r *Row) dropRaw() {
 	r.rawLine = ""
 	r.rawPrefix = nil
+	r.rawBelow = nil
 	r.parsed = false
 }
 
@@ -83,6 +109,7 @@ func (r *Row) dropRaw() {
 // input outright — a blank line or a comment that nothing else records.
 func (r *Row) dropLine() {
 	r.rawLine = ""
+	r.rawBelow = nil
 	r.parsed = false
 }
 
@@ -114,43 +141,306 @@ func (r *Row) IsCommented() bool { return r.commented }
 
 // SetCommented marks the row as commented out, or restores it, and returns r
 // for chaining.
+//
+// It keeps the lines above the row, so commenting a key out or back in is a
+// one-line diff, unless the row has shadows: those are written above a live
+// row and below a commented one, and the lines above are then rebuilt from the
+// model.
 func (r *Row) SetCommented(b bool) *Row {
 	if r.commented != b {
-		r.dropRaw()
+		if len(r.shadows) > 0 {
+			r.dropRaw()
+		} else {
+			r.dropLine()
+		}
 	}
 	r.commented = b
 	return r
 }
 
+// A Shadow is a commented-out alternative of a row's value, as
+// [Row.ShadowList] describes it:
+//
+//	# DB_URL=postgres://old # used before the migration
+//	DB_URL=postgres://new
+type Shadow struct {
+	// Value is the alternative value, with quoting and escaping resolved.
+	Value string
+
+	// Comment is the comment trailing the shadow on its line, without its "#".
+	Comment string
+
+	// Label is the profile the shadow belongs to, named in its comment by
+	// [ProfileMarker], or empty.
+	Label string
+
+	// Raw is the line stating the shadow as the row will write it, or empty
+	// when the shadow is written afresh from the fields above.
+	Raw string
+}
+
 // NumShadows returns how many shadows the row carries.
 func (r *Row) NumShadows() int { return len(r.shadows) }
 
-// Shadows iterates the row's shadows — commented-out alternatives of the value,
-// kept in the order they were seen.
+// ShadowList returns the row's shadows in the order they were seen, each with
+// the comment trailing it. The slice is the caller's; changing it does not
+// change the row.
+func (r *Row) ShadowList() []Shadow {
+	if len(r.shadows) == 0 {
+		return nil
+	}
+	lines := r.shadowLines()
+	out := make([]Shadow, len(r.shadows))
+	for i, s := range r.shadows {
+		out[i] = Shadow{Value: s.value, Comment: s.inline, Label: profileOf(s.inline)}
+		if l, ok := lines[s.value]; ok {
+			out[i].Raw = r.rawPrefix[l]
+		}
+	}
+	return out
+}
+
+// Shadows iterates the values of the row's shadows — commented-out
+// alternatives of the value — in the order they were seen. [Row.ShadowList]
+// has the comments as well.
 func (r *Row) Shadows() iter.Seq[string] {
-	return slices.Values(r.shadows)
+	return func(yield func(string) bool) {
+		for _, s := range r.shadows {
+			if !yield(s.value) {
+				return
+			}
+		}
+	}
 }
 
 // HasShadow reports whether the row already carries the given shadow.
 func (r *Row) HasShadow(s string) bool {
-	return slices.Contains(r.shadows, s)
+	return r.shadowIndex(s) >= 0
+}
+
+// shadowIndex returns the position of shadow s, or -1 when the row lacks it.
+func (r *Row) shadowIndex(s string) int {
+	return slices.IndexFunc(r.shadows, func(sh shadow) bool { return sh.value == s })
 }
 
 // AddShadow records an alternative value for the row, ignoring duplicates, and
 // returns r for chaining.
 func (r *Row) AddShadow(s string) *Row {
-	if !r.HasShadow(s) {
-		r.shadows = append(r.shadows, s)
-		r.dropRaw()
+	return r.AddShadowWithComment(s, "")
+}
+
+// AddShadowWithComment records an alternative value for the row with a comment
+// trailing it, and returns r for chaining. A value the row already carries as a
+// shadow is ignored.
+//
+// In a row read from a file the new shadow is a line of its own after the
+// shadows already there, and the lines around it are left as they were.
+func (r *Row) AddShadowWithComment(s, comment string) *Row {
+	if r.HasShadow(s) {
+		return r
+	}
+	sh := shadow{value: s, inline: comment}
+	if r.commented || (!r.parsed && len(r.rawPrefix) == 0) {
+		// A commented row's shadows are written below it, from the model.
+		r.shadows = append(r.shadows, sh)
+		r.dropLine()
+		return r
 	}
+	at := len(r.rawPrefix)
+	if n := len(r.shadows); n > 0 {
+		at = r.shadowLine(r.shadows[n-1].value) + 1
+		if at == 0 {
+			r.shadows = append(r.shadows, sh)
+			r.dropRaw()
+			return r
+		}
+	}
+	r.shadows = append(r.shadows, sh)
+	r.rawPrefix = slices.Insert(r.rawPrefix, at, shadowText(r.key, sh))
 	return r
 }
 
+// RemoveShadow deletes the shadow with the given value, reporting whether the
+// row had it. In a row read from a file the line stating it goes, and nothing
+// else.
+func (r *Row) RemoveShadow(s string) bool {
+	i := r.shadowIndex(s)
+	if i < 0 {
+		return false
+	}
+	r.removeShadowAt(i)
+	return true
+}
+
+// removeShadowAt deletes the i-th shadow, and in a row read from a file the
+// line stating it.
+func (r *Row) removeShadowAt(i int) {
+	line := r.shadowLine(r.shadows[i].value)
+	above := r.shadowsAbove()
+	r.shadows = slices.Delete(r.shadows, i, i+1)
+	switch {
+	case line >= 0:
+		r.rawPrefix = slices.Delete(r.rawPrefix, line, line+1)
+	case above:
+		r.dropRaw()
+	default:
+		r.dropLine()
+	}
+}
+
+// Promote makes shadow the row's value and demotes the current value to a
+// shadow in its place. It reports false, and changes nothing, when the row
+// carries no such shadow.
+//
+// The demoted value takes the position the shadow had, so in a file that was
+// read the change is two lines: the commented line now states the old value
+// and the assignment the new one. Everything else above the row stands. A
+// comment trailing either value travels with it, since it says something about
+// that value — a profile label, or why it is there — except that a plain
+// comment on the assignment stays where it is when the shadow has none to
+// swap it for. A demoted value the row already carries as another shadow is not
+// stated twice; the promoted shadow's line then goes instead.
+func (r *Row) Promote(shadow string) bool {
+	i := r.shadowIndex(shadow)
+	if i < 0 {
+		return false
+	}
+	if shadow == r.value {
+		return true
+	}
+
+	demoted := r.demoted()
+	line := r.shadowLine(shadow)
+	r.value = shadow
+	if r.shadows[i].inline != "" || profileOf(r.inline) != "" {
+		r.inline = r.shadows[i].inline
+	} else {
+		demoted.inline = ""
+	}
+	if r.HasShadow(demoted.value) {
+		r.shadows = slices.Delete(r.shadows, i, i+1)
+		if line >= 0 {
+			r.rawPrefix = slices.Delete(r.rawPrefix, line, line+1)
+		}
+	} else {
+		r.shadows[i] = demoted
+		if line >= 0 {
+			r.rawPrefix[line] = shadowText(r.key, demoted)
+		}
+	}
+
+	if line < 0 && r.shadowsAbove() {
+		r.dropRaw()
+	} else {
+		r.dropLine()
+	}
+	return true
+}
+
+// Rotate promotes the row's first shadow and moves the value it displaces to
+// the end of the list, the line right above the assignment, so that calling it
+// again and again cycles the row through every value in the order the file
+// lists them. It reports false when the row has no shadows.
+func (r *Row) Rotate() bool {
+	if len(r.shadows) == 0 {
+		return false
+	}
+	first, last := r.shadowLine(r.shadows[0].value), r.shadowLine(r.shadows[len(r.shadows)-1].value)
+	n := len(r.shadows)
+	r.Promote(r.shadows[0].value)
+	if n == 1 || len(r.shadows) < n {
+		// A lone shadow is already at the end, and a displaced value the row
+		// carried as a shadow already keeps the place it had.
+		return true
+	}
+
+	r.shadows = append(r.shadows[1:], r.shadows[0])
+	if !r.shadowsAbove() {
+		return true
+	}
+	if first < 0 || last < 0 {
+		r.dropRaw()
+		return true
+	}
+	demoted := r.rawPrefix[first]
+	r.rawPrefix = slices.Insert(slices.Delete(r.rawPrefix, first, first+1), last, demoted)
+	return true
+}
+
+// shadowsAbove reports whether the lines recorded above the row are where its
+// shadows are written, which is the case for a live row: see [Encoder].
+func (r *Row) shadowsAbove() bool {
+	return !r.commented && len(r.rawPrefix) > 0
+}
+
+// shadowLine returns the index of the recorded line above the row stating
+// shadow s, or -1 when there is none. Asking after several shadows, use
+// shadowLines once instead.
+func (r *Row) shadowLine(s string) int {
+	if i, ok := r.shadowLines()[s]; ok {
+		return i
+	}
+	return -1
+}
+
+// shadowLines reads the recorded lines above the row once and returns, for
+// each shadow value they state, the index of the first line stating it. It is
+// nil for a row whose shadows are written afresh.
+func (r *Row) shadowLines() map[string]int {
+	if !r.shadowsAbove() {
+		return nil
+	}
+	sc := newScanner(strings.NewReader(""), newConfig(nil))
+	var info lineInfo
+	lines := make(map[string]int)
+	for i, l := range r.rawPrefix {
+		if sc.classify([]byte(l), &info) != nil || info.kind != lineCommented || info.key != r.key {
+			continue
+		}
+		if _, seen := lines[info.value]; !seen {
+			lines[info.value] = i
+		}
+	}
+	return lines
+}
+
+// demoted returns the row's value as a shadow, with the comment trailing it.
+func (r *Row) demoted() shadow {
+	return shadow{value: r.value, inline: r.inline}
+}
+
+// shadowText renders a shadow as the encoder writes one.
+func shadowText(key string, s shadow) string {
+	text := "# " + key + "=" + string(appendMinimal(nil, s.value))
+	if s.inline != "" {
+		text += " # " + s.inline
+	}
+	return text
+}
+
+// A shadow is one commented-out alternative of a row's value, with the comment
+// trailing it on its line.
+type shadow struct {
+	value  string
+	inline string
+
+	// def is the line of the live statement the shadow was demoted from, when
+	// a later one of the same key displaced it, or 0 for a shadow the input
+	// states as one. It tells the two apart when a fix drops the statement.
+	def int
+}
+
 // addParsedShadow records a shadow that came from the input, where the verbatim
-// lines already account for it and must not be discarded.
-func (r *Row) addParsedShadow(s string) {
-	if !r.HasShadow(s) {
+// lines already account for it and must not be discarded. A value stated both
+// as a shadow and as a displaced statement is kept as the shadow it was also
+// written as.
+func (r *Row) addParsedShadow(s shadow) {
+	i := r.shadowIndex(s.value)
+	switch {
+	case i < 0:
 		r.shadows = append(r.shadows, s)
+	case s.def == 0:
+		r.shadows[i].def = 0
 	}
 }
 
@@ -161,10 +451,14 @@ func (r *Row) addParsedShadow(s string) {
 // mentions a key without giving it a value is stating that the key exists, not
 // that it is now blank.
 func (r *Row) merge(other *Row) {
+	if other.value != "" {
+		r.supersede(other)
+	}
 	if other.value != "" && other.value != r.value {
 		r.value = other.value
 		r.rawLine = other.rawLine
 		r.rawPrefix = slices.Clone(other.rawPrefix)
+		r.rawBelow = slices.Clone(other.rawBelow)
 		r.parsed = other.parsed
 	}
 	if r.inline == "" && other.inline != "" {
@@ -177,7 +471,7 @@ func (r *Row) merge(other *Row) {
 		r.dropRaw()
 	}
 	for _, s := range other.shadows {
-		if !r.HasShadow(s) {
+		if !r.HasShadow(s.value) {
 			r.shadows = append(r.shadows, s)
 			r.dropRaw()
 		}
@@ -190,6 +484,8 @@ func (r *Row) clone() *Row {
 	c := *r
 	c.shadows = slices.Clone(r.shadows)
 	c.rawPrefix = slices.Clone(r.rawPrefix)
+	c.rawBelow = slices.Clone(r.rawBelow)
+	c.overrode = slices.Clone(r.overrode)
 	return &c
 }
 
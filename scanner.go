@@ -28,12 +28,16 @@ type lineInfo struct {
 	// raw is the line as it appeared, without its terminator.
 	raw string
 
+	// line is the 1-based number of the line, which a row keeps as part of
+	// its [Origin].
+	line int
+
 	// key and value are set for lineAssign and lineCommented; key is
 	// normalised, value has quoting and escapes resolved.
 	key, value string
 
 	// text is the comment text: the body of a lineComment or lineHeader, or
-	// the trailing comment of a lineAssign.
+	// the trailing comment of a lineAssign or lineCommented.
 	text string
 
 	// check is what only a check needs to know, nil while parsing. It points
@@ -153,7 +157,7 @@ func (s *scanner) readLine() ([]byte, error) {
 
 // classify decides what the line is and fills out.
 func (s *scanner) classify(line []byte, out *lineInfo) error {
-	*out = lineInfo{}
+	*out = lineInfo{line: s.lineNo}
 	if s.check {
 		s.lc = lineCheck{line: s.lineNo}
 		out.check = &s.lc
@@ -176,12 +180,20 @@ func (s *scanner) classify(line []byte, out *lineInfo) error {
 		// A commented-out assignment is a shadow or an inert row; anything
 		// else is prose. Prose keeps only its raw form — the text is a
 		// substring of it, taken without allocating when a row asks for it.
+		// An IgnoreMarker line is prose too, though "envi:ignore x" reads
+		// as an assignment with a colon.
 		body := stripCommentMarker(trimmed)
-		if key, value, _, perr := s.parseAssign(body); perr == nil {
+		if isIgnoreLine(body) {
+			out.kind = lineComment
+			out.raw = string(line)
+			return nil
+		}
+		if key, value, comment, perr := s.parseAssign(body); perr == nil {
 			out.kind = lineCommented
 			out.raw = string(line)
 			out.key = NormalizeKey(string(key))
 			out.value = string(value)
+			out.text = string(comment)
 			return nil
 		}
 		out.kind = lineComment
@@ -376,6 +388,12 @@ func unescape(dst, src []byte) []byte {
 	return dst
 }
 
+// isIgnoreLine reports whether a comment's text starts with [IgnoreMarker].
+func isIgnoreLine(body []byte) bool {
+	rest, ok := bytes.CutPrefix(body, []byte(IgnoreMarker))
+	return ok && (len(rest) == 0 || rest[0] == ' ' || rest[0] == '\t')
+}
+
 // stripCommentMarker removes the leading hashes of a comment and one space
 // after them, leaving the text.
 func stripCommentMarker(b []byte) []byte {
@@ -391,12 +409,13 @@ func stripCommentMarker(b []byte) []byte {
 
 // specialByte returns the first byte of a bare value that another reader of the
 // file is liable to treat as more than itself — a shell expanding $VAR or a
-// backquoted command, a parser stumbling over a stray quote or backslash — or 0
-// when the value holds none. A quoted value is not asked: quoting is the answer.
+// backquoted command or splitting the value at a space, a parser stumbling over
+// a stray quote or backslash — or 0 when the value holds none. A quoted value
+// is not asked: quoting is the answer.
 func specialByte(v []byte) byte {
 	for _, c := range v {
 		switch c {
-		case '$', '`', '"', '\'', '\\':
+		case '$', '`', '"', '\'', '\\', ' ', '\t':
 			return c
 		}
 	}
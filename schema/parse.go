@@ -0,0 +1,180 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// Parse reads a schema in either of its two forms, told apart by the first
+// byte that is not white space: '{' starts JSON, anything else is a .env file.
+//
+// In JSON the schema is one object, each member declaring the key it is named
+// after with the fields of [Field], in the order written:
+//
+//	{
+//	  "DATABASE_URL": {"type": "url", "required": true, "secret": true},
+//	  "LOG_LEVEL": {"type": "enum", "values": ["debug", "info"], "default": "info"}
+//	}
+//
+// As a .env file each live row declares its key. Its value is the default, the
+// comment above it the description, and its trailing comment a list of words
+// saying the rest:
+//
+//   - a type: string, int, bool, url or duration;
+//   - enum(a,b,c), an enum of the values between the parentheses;
+//   - regex(PATTERN), a value PATTERN matches as a whole;
+//   - required, and secret or [envi.SecretMarker];
+//   - deprecated, or deprecated(TEXT) saying what to do instead.
+//
+// A word that is none of these is an error, so a misspelt "requried" is not
+// taken for a description. Commented-out rows declare nothing.
+func Parse(r io.Reader) (*Schema, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if t := bytes.TrimLeft(data, " \t\r\n"); len(t) > 0 && t[0] == '{' {
+		return parseJSON(t)
+	}
+	return parseEnv(data)
+}
+
+// Load reads the schema in the named file. See [Parse].
+func Load(path string) (*Schema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	s, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// parseJSON reads the JSON form. The object is walked member by member rather
+// than decoded into a map, which would lose the order of the keys.
+func parseJSON(data []byte) (*Schema, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	var fields []Field
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("schema: %w", err)
+		}
+		key, _ := tok.(string)
+		var f Field
+		if err := dec.Decode(&f); err != nil {
+			return nil, fmt.Errorf("schema: %s: %w", key, err)
+		}
+		f.Key = key
+		fields = append(fields, f)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("schema: more after the object")
+	}
+	return New(fields...)
+}
+
+// parseEnv reads the .env form.
+func parseEnv(data []byte) (*Schema, error) {
+	doc, err := envi.ParseBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	var fields []Field
+	var errs []error
+	for r := range doc.Rows() {
+		if r.IsCommented() {
+			continue
+		}
+		f := Field{
+			Key:         r.Key(),
+			Default:     r.Value(),
+			Description: strings.TrimSpace(r.Comment()),
+		}
+		if err := f.annotate(r.InlineComment()); err != nil {
+			errs = append(errs, fmt.Errorf("schema: line %d: %s: %w", r.Origin().Line, r.Key(), err))
+			continue
+		}
+		fields = append(fields, f)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return New(fields...)
+}
+
+// annotate sets what the words of a trailing comment say about the field.
+func (f *Field) annotate(comment string) error {
+	for len(comment) > 0 {
+		var word, arg string
+		var hasArg bool
+		word, arg, hasArg, comment = nextWord(comment)
+		switch {
+		case word == "":
+		case word == "required" && !hasArg:
+			f.Required = true
+		case (word == "secret" || word == envi.SecretMarker) && !hasArg:
+			f.Secret = true
+		case word == "deprecated":
+			f.Deprecated = arg
+			if !hasArg || arg == "" {
+				f.Deprecated = "no longer used"
+			}
+		case word == string(TypeEnum) && hasArg:
+			f.Type = TypeEnum
+			f.Values = strings.Split(arg, ",")
+			for i, v := range f.Values {
+				f.Values[i] = strings.TrimSpace(v)
+			}
+		case word == string(TypeRegex) && hasArg:
+			f.Type, f.Pattern = TypeRegex, arg
+		case word == string(TypeEnum) || word == string(TypeRegex):
+			return fmt.Errorf("%s needs its argument in parentheses", word)
+		case !hasArg && (word == string(TypeString) || word == string(TypeInt) ||
+			word == string(TypeBool) || word == string(TypeURL) || word == string(TypeDuration)):
+			f.Type = Type(word)
+		default:
+			return fmt.Errorf("unknown annotation %q", word)
+		}
+	}
+	return nil
+}
+
+// nextWord splits the first word off s. A word followed straight away by '('
+// takes an argument, which runs to the first ')' that ends s or is followed by
+// a space, so that a pattern may hold parentheses of its own.
+func nextWord(s string) (word, arg string, hasArg bool, rest string) {
+	s = strings.TrimLeft(s, " \t")
+	end := strings.IndexAny(s, " \t(")
+	if end < 0 {
+		return s, "", false, ""
+	}
+	if s[end] != '(' {
+		return s[:end], "", false, s[end:]
+	}
+	word, s = s[:end], s[end+1:]
+	for i := 0; i < len(s); i++ {
+		if s[i] == ')' && (i+1 == len(s) || s[i+1] == ' ' || s[i+1] == '\t') {
+			return word, s[:i], true, s[i+1:]
+		}
+	}
+	// An argument left open is no annotation at all.
+	return word + "(" + s, "", false, ""
+}
@@ -0,0 +1,310 @@
+// Package schema declares what a .env document is expected to configure, and
+// checks a document against the declaration.
+//
+// A schema names each key with its type, whether it is required, its default,
+// a description, whether it is deprecated and whether it is secret. It is most
+// often kept beside the files it describes as .env.schema, written in the
+// format of a .env file itself — the value is the default, the comment above
+// the description, and the trailing comment the rest:
+//
+//	# Where the service keeps its data.
+//	DATABASE_URL= # url required secret
+//	# How much to log.
+//	LOG_LEVEL=info # enum(debug,info,warn,error)
+//	PORT=8080 # int
+//	OLD_HOST= # deprecated(use DATABASE_URL)
+//
+// or as JSON, one object per key; see [Parse]. Checking a document records
+// what is wrong with it in an [envi.Report], each finding naming its key and,
+// for a document read from a file, its line:
+//
+//	s, err := schema.Load(".env.schema")
+//	env, err := envi.Load(".env")
+//	if err := s.Validate(env).Err(); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// A value the schema accepts for a type is one [envi.GetAs] reads as that type,
+// so a document that passes is one package bind will bind.
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// A Type says what form a key's value takes.
+type Type string
+
+const (
+	// TypeString accepts any value. It is what a field of no type means.
+	TypeString Type = "string"
+
+	// TypeInt accepts a decimal integer.
+	TypeInt Type = "int"
+
+	// TypeBool accepts what [strconv.ParseBool] reads: 1, t, true, 0, f, false
+	// and their upper-case forms.
+	TypeBool Type = "bool"
+
+	// TypeURL accepts an absolute URL, one with a scheme.
+	TypeURL Type = "url"
+
+	// TypeDuration accepts what [time.ParseDuration] reads, such as "1m30s".
+	TypeDuration Type = "duration"
+
+	// TypeEnum accepts one of the field's Values.
+	TypeEnum Type = "enum"
+
+	// TypeRegex accepts a value the field's Pattern matches as a whole.
+	TypeRegex Type = "regex"
+)
+
+// The rules a schema's findings are recorded under. They switch off with
+// [envi.WithoutRules] like the rules of package envi.
+const (
+	// RuleRequired reports a required key the document leaves out, leaves
+	// empty or only carries commented out.
+	RuleRequired envi.Rule = "schema-required"
+
+	// RuleType reports a value that is not of its key's type.
+	RuleType envi.Rule = "schema-type"
+
+	// RuleDeprecated reports a deprecated key the document still sets.
+	RuleDeprecated envi.Rule = "schema-deprecated"
+
+	// RuleUnknown reports a key the schema does not declare.
+	RuleUnknown envi.Rule = "schema-unknown"
+)
+
+// Rules describes the rules a schema's findings are recorded under, for
+// [envi.WithKnownRules] and the Rules of an [envi.FileReport].
+func Rules() []envi.RuleInfo {
+	return []envi.RuleInfo{
+		{Rule: RuleRequired, Severity: envi.SeverityError, Summary: "A required key the document leaves out, leaves empty or only carries commented out."},
+		{Rule: RuleType, Severity: envi.SeverityError, Summary: "A value that is not of its key's type."},
+		{Rule: RuleDeprecated, Severity: envi.SeverityWarning, Summary: "A deprecated key the document still sets."},
+		{Rule: RuleUnknown, Severity: envi.SeverityWarning, Summary: "A key the schema does not declare."},
+	}
+}
+
+// A Field declares one key.
+type Field struct {
+	// Key is the key declared, normalised the way every key is.
+	Key string `json:"-"`
+
+	// Type is the form of the value; empty means [TypeString].
+	Type Type `json:"type,omitempty"`
+
+	// Values are the values a [TypeEnum] field accepts, and Pattern the
+	// regular expression, in the syntax of package regexp, a [TypeRegex] field's
+	// value must match as a whole.
+	Values  []string `json:"values,omitempty"`
+	Pattern string   `json:"pattern,omitempty"`
+
+	// Required means the document must give the key a non-empty value.
+	Required bool `json:"required,omitempty"`
+
+	// Default is the value a program falls back on when the document leaves the
+	// key out, empty for none. It is documentation: a schema checks it is of
+	// the field's type, and a document that leaves out a required key is at
+	// fault whatever the default.
+	Default string `json:"default,omitempty"`
+
+	// Description says what the key is for.
+	Description string `json:"description,omitempty"`
+
+	// Deprecated, when not empty, marks the key as on its way out and says
+	// what to do instead: "use DATABASE_URL".
+	Deprecated string `json:"deprecated,omitempty"`
+
+	// Secret marks the value as one not to show. Findings about the key never
+	// quote it, and [Schema.Redaction] hides it.
+	Secret bool `json:"secret,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// A Schema is a set of fields, each declaring one key.
+type Schema struct {
+	fields []Field
+	byKey  map[string]int
+}
+
+// New builds a schema from fields, in the order given. A field with a key
+// another has, a type this package does not know, an enum with no values, a
+// pattern that does not compile or a default not of the field's type is an
+// error, and every such error is reported at once.
+func New(fields ...Field) (*Schema, error) {
+	s := &Schema{byKey: make(map[string]int, len(fields))}
+	var errs []error
+	for _, f := range fields {
+		f.Key = envi.NormalizeKey(f.Key)
+		f.Values = slices.Clone(f.Values)
+		if err := f.compile(); err != nil {
+			errs = append(errs, fmt.Errorf("schema: %s: %w", f.Key, err))
+			continue
+		}
+		if _, dup := s.byKey[f.Key]; dup {
+			errs = append(errs, fmt.Errorf("schema: %s: declared twice", f.Key))
+			continue
+		}
+		s.byKey[f.Key] = len(s.fields)
+		s.fields = append(s.fields, f)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// compile checks the field makes sense, and prepares its pattern.
+func (f *Field) compile() error {
+	switch f.Type {
+	case "":
+		f.Type = TypeString
+	case TypeString, TypeInt, TypeBool, TypeURL, TypeDuration:
+	case TypeEnum:
+		if len(f.Values) == 0 {
+			return errors.New("an enum needs values")
+		}
+	case TypeRegex:
+		re, err := regexp.Compile(`^(?:` + f.Pattern + `)$`)
+		if err != nil {
+			return err
+		}
+		f.re = re
+	default:
+		return fmt.Errorf("unknown type %q", f.Type)
+	}
+	if f.Key == "" {
+		return errors.New("a field needs a key")
+	}
+	if f.Default != "" {
+		if msg := f.check(f.Default); msg != "" {
+			return fmt.Errorf("default %q %s", f.Default, msg)
+		}
+	}
+	return nil
+}
+
+// check returns what is wrong with value as a value of the field, "" when
+// nothing is. It reads the value the way [envi.GetAs] would.
+func (f *Field) check(value string) string {
+	doc := envi.New(envi.NewRow(f.Key, value))
+	var err error
+	var want string
+	switch f.Type {
+	case TypeInt:
+		_, err = doc.Int(f.Key)
+		want = "an integer"
+	case TypeBool:
+		_, err = doc.Bool(f.Key)
+		want = "a bool"
+	case TypeDuration:
+		_, err = doc.Duration(f.Key)
+		want = "a duration"
+	case TypeURL:
+		var u *url.URL
+		if u, err = doc.URL(f.Key); err == nil && u.Scheme == "" {
+			return "is not an absolute URL"
+		}
+		want = "a URL"
+	case TypeEnum:
+		if !slices.Contains(f.Values, value) {
+			return "is not one of " + strings.Join(f.Values, ", ")
+		}
+	case TypeRegex:
+		if !f.re.MatchString(value) {
+			return "does not match " + f.Pattern
+		}
+	}
+	if err != nil {
+		return "is not " + want
+	}
+	return ""
+}
+
+// Fields returns the schema's fields, in the order they were declared. The
+// slice is a copy.
+func (s *Schema) Fields() []Field { return slices.Clone(s.fields) }
+
+// Field returns the field declaring key, and whether there is one.
+func (s *Schema) Field(key string) (Field, bool) {
+	i, ok := s.byKey[envi.NormalizeKey(key)]
+	if !ok {
+		return Field{}, false
+	}
+	return s.fields[i], true
+}
+
+// Redaction returns [envi.DefaultRedaction] extended with the keys the schema
+// marks secret, for showing a document it describes.
+func (s *Schema) Redaction() *envi.Redaction {
+	p := envi.DefaultRedaction()
+	for _, f := range s.fields {
+		if f.Secret {
+			p.Patterns = append(p.Patterns, f.Key)
+		}
+	}
+	return p
+}
+
+// Validate checks e against the schema and returns what it found. See
+// [Schema.Check].
+func (s *Schema) Validate(e *envi.Env) *envi.Report {
+	var rep envi.Report
+	s.Check(e, &rep)
+	return &rep
+}
+
+// Check checks e against the schema, recording what it finds in rep. Given the
+// report of an [envi.Check] over the same document, the schema's findings join
+// the parser's, and the rules switched off there stay off.
+//
+// Each live row is checked in the order of the document, against the field
+// declaring its key; each required key the document does not set is reported
+// after, in the order of the schema, with no line. A commented-out row
+// configures nothing and is passed over, and so is an empty value of a key
+// that is not required.
+func (s *Schema) Check(e *envi.Env, rep *envi.Report) {
+	set := make(map[string]bool)
+	for r := range e.Rows() {
+		if r.IsCommented() {
+			continue
+		}
+		key, value, line := r.Key(), r.Value(), r.Origin().Line
+		i, ok := s.byKey[key]
+		if !ok {
+			rep.Add(envi.Problem{Rule: RuleUnknown, Severity: envi.SeverityWarning, Line: line, Key: key, Msg: "key is not in the schema"})
+			continue
+		}
+		f := &s.fields[i]
+		if f.Deprecated != "" {
+			rep.Add(envi.Problem{Rule: RuleDeprecated, Severity: envi.SeverityWarning, Line: line, Key: key, Msg: "key is deprecated: " + f.Deprecated})
+		}
+		if value == "" {
+			continue
+		}
+		set[key] = true
+		if msg := f.check(value); msg != "" {
+			shown := "value"
+			if !f.Secret {
+				shown = "value " + strconv.Quote(rep.Redact(key, value))
+			}
+			rep.Add(envi.Problem{Rule: RuleType, Severity: envi.SeverityError, Line: line, Key: key, Msg: shown + " " + msg})
+		}
+	}
+	for _, f := range s.fields {
+		if f.Required && !set[f.Key] {
+			rep.Add(envi.Problem{Rule: RuleRequired, Severity: envi.SeverityError, Key: f.Key, Msg: "required key is not set"})
+		}
+	}
+}
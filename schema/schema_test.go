@@ -0,0 +1,146 @@
+package schema_test
+
+import (
+	"strings"
+	"testing"
+
+	envi "github.com/efureev/envi/v2"
+	"github.com/efureev/envi/v2/schema"
+)
+
+const envSchema = `# Where the service keeps its data.
+DATABASE_URL= # url required secret
+# How much to log.
+LOG_LEVEL=info # enum(debug, info,warn)
+PORT=8080 # int
+TIMEOUT=5s # duration
+DEBUG=false # bool
+NAME= # regex(([a-z]+)-(dev|prod))
+OLD_HOST= # deprecated(use DATABASE_URL)
+`
+
+const jsonSchema = `{
+  "DATABASE_URL": {"type": "url", "required": true, "secret": true,
+                   "description": "Where the service keeps its data."},
+  "LOG_LEVEL": {"type": "enum", "values": ["debug", "info", "warn"], "default": "info",
+                "description": "How much to log."},
+  "PORT": {"type": "int", "default": "8080"},
+  "TIMEOUT": {"type": "duration", "default": "5s"},
+  "DEBUG": {"type": "bool", "default": "false"},
+  "NAME": {"type": "regex", "pattern": "([a-z]+)-(dev|prod)"},
+  "OLD_HOST": {"deprecated": "use DATABASE_URL"}
+}`
+
+func mustParse(t *testing.T, src string) *schema.Schema {
+	t.Helper()
+
+	s, err := schema.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// The two forms say the same thing.
+func TestParseForms(t *testing.T) {
+	t.Parallel()
+
+	for name, src := range map[string]string{"env": envSchema, "json": jsonSchema} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := mustParse(t, src)
+			var keys []string
+			for _, f := range s.Fields() {
+				keys = append(keys, f.Key)
+			}
+			if got, want := strings.Join(keys, " "), "DATABASE_URL LOG_LEVEL PORT TIMEOUT DEBUG NAME OLD_HOST"; got != want {
+				t.Errorf("keys = %s, want %s", got, want)
+			}
+			f, _ := s.Field("database_url")
+			if f.Type != schema.TypeURL || !f.Required || !f.Secret || f.Description != "Where the service keeps its data." {
+				t.Errorf("DATABASE_URL = %+v", f)
+			}
+			f, _ = s.Field("LOG_LEVEL")
+			if f.Type != schema.TypeEnum || strings.Join(f.Values, ",") != "debug,info,warn" || f.Default != "info" {
+				t.Errorf("LOG_LEVEL = %+v", f)
+			}
+			if f, _ = s.Field("OLD_HOST"); f.Type != schema.TypeString || f.Deprecated != "use DATABASE_URL" {
+				t.Errorf("OLD_HOST = %+v", f)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	s := mustParse(t, envSchema)
+	env, err := envi.ParseString("PORT=abc\nLOG_LEVEL=loud\nTIMEOUT=5\nDEBUG=yes\n" +
+		"NAME=app-prod\nOLD_HOST=h\nEXTRA=1\n# DATABASE_URL=postgres://x\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := s.Validate(env)
+	const want = `1: error: schema-type: value "abc" is not an integer (PORT)
+2: error: schema-type: value "loud" is not one of debug, info, warn (LOG_LEVEL)
+3: error: schema-type: value "5" is not a duration (TIMEOUT)
+4: error: schema-type: value "yes" is not a bool (DEBUG)
+6: warning: schema-deprecated: key is deprecated: use DATABASE_URL (OLD_HOST)
+7: warning: schema-unknown: key is not in the schema (EXTRA)
+DATABASE_URL: error: schema-required: required key is not set
+`
+	if got := rep.String(); got != want {
+		t.Errorf("got\n%swant\n%s", got, want)
+	}
+
+	env, _ = envi.ParseString("DATABASE_URL=postgres://db/app\nPORT=80\nNAME=App-prod\n")
+	if got := s.Validate(env).String(); got != "3: error: schema-type: value \"App-prod\" does not match ([a-z]+)-(dev|prod) (NAME)\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// A secret value is never quoted, and the rules switch off like built-in ones.
+func TestCheckJoinsAReport(t *testing.T) {
+	t.Parallel()
+
+	s := mustParse(t, envSchema)
+	env, rep, err := envi.CheckString("DATABASE_URL=db.internal\nEMPTY=\n", envi.WithoutRules(schema.RuleUnknown))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Check(env, rep)
+	const want = "2: warning: empty-value: value is empty (EMPTY)\n" +
+		"1: error: schema-type: value is not an absolute URL (DATABASE_URL)\n"
+	if got := rep.String(); got != want {
+		t.Errorf("got\n%swant\n%s", got, want)
+	}
+	if !s.Redaction().MatchKey("DATABASE_URL") {
+		t.Error("Redaction does not cover a secret field")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct{ src, want string }{
+		{"PORT= # integer\n", `line 1: PORT: unknown annotation "integer"`},
+		{"PORT= # int requried\n", `unknown annotation "requried"`},
+		{"L= # enum\n", "enum needs its argument in parentheses"},
+		{"R= # regex(a\n", `unknown annotation "regex(a"`},
+		{"R= # regex([)\n", "R: error parsing regexp"},
+		{"PORT=eighty # int\n", `PORT: default "eighty" is not an integer`},
+		{`{"A": {"type": "float"}}`, `A: unknown type "float"`},
+		{`{"A": {"type": "enum"}}`, "A: an enum needs values"},
+		{`{"A": {"typo": "int"}}`, `A: json: unknown field "typo"`},
+		{`{"A": {}, "a": {}}`, "A: declared twice"},
+		{`{"A": {"type": "int"}} trailing`, "more after the object"},
+		{`{"A": {}} {}`, "more after the object"},
+	}
+	for _, tt := range tests {
+		_, err := schema.Parse(strings.NewReader(tt.src))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: err = %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
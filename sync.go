@@ -0,0 +1,97 @@
+package envi
+
+// SyncOptions configures [Env.SyncFrom].
+type SyncOptions struct {
+	// Prune comments out the live rows whose key the template does not
+	// mention at all. Without it they are only reported. Commenting out rather
+	// than deleting keeps the value to hand should the removal from the
+	// template turn out to be a mistake.
+	Prune bool
+}
+
+// SyncFrom brings e in line with template — usually a working .env with the
+// .env.example that documents it — and returns what it did.
+//
+// A key the template configures and e does not mention is added with the
+// template's value, comment and trailing comment, as a [ChangeAdded]. It joins
+// the block of its prefix when e has one. When e has no row of that prefix at
+// all and the template keeps the key in a block, a block with the template's
+// header comment is started for it, so the new keys arrive grouped the way the
+// template groups them.
+//
+// A live row of e whose key the template does not mention is reported as a
+// [ChangeRemoved], and with [SyncOptions.Prune] commented out.
+//
+// Nothing else is touched. A value e already holds is never changed, however
+// it differs from the template's, and a key e only has commented out counts as
+// present: commenting a key out is a decision, and undoing it is not what
+// syncing is for. A commented-out row of the template adds nothing, since it
+// configures nothing, but does keep the same key in e from being reported.
+//
+// The changes come in the order [Env.Diff] uses: those to rows of e in its
+// order, then the additions in the template's. A nil template leaves e alone.
+func (e *Env) SyncFrom(template *Env, opts SyncOptions) *Delta {
+	d := &Delta{}
+	if template == nil {
+		return d
+	}
+
+	for r := range e.Rows() {
+		if r.commented || template.Has(r.key) {
+			continue
+		}
+		d.changes = append(d.changes, Change{Kind: ChangeRemoved, Key: r.key, Old: r.value})
+		if opts.Prune {
+			r.SetCommented(true)
+		}
+	}
+
+	for it := range template.Items() {
+		switch v := it.(type) {
+		case *Row:
+			e.syncRow(d, v, nil)
+		case *Block:
+			for _, r := range v.rows {
+				e.syncRow(d, r, v)
+			}
+		}
+	}
+	return d
+}
+
+// syncRow adds one template row to e if e lacks it. from is the block holding
+// the row in the template, nil at top level.
+func (e *Env) syncRow(d *Delta, tr *Row, from *Block) {
+	if tr.commented || e.Has(tr.key) {
+		return
+	}
+	e.addLike(tr, from)
+	d.changes = append(d.changes, Change{Kind: ChangeAdded, Key: tr.key, New: tr.value})
+}
+
+// addLike adds a copy of tr, a row e lacks, with its value and comments. from
+// is the block holding tr in its own document, nil at top level.
+func (e *Env) addLike(tr *Row, from *Block) {
+	r := &Row{key: tr.key, value: tr.value, comment: tr.comment, inline: tr.inline}
+
+	if prefix, _ := splitKey(tr.key); from != nil && prefix != "" && e.Block(prefix) == nil && !e.hasPrefix(prefix) {
+		// The other document's block becomes one here. Starting it while e
+		// holds top-level rows of the prefix would pull them into it and move
+		// them, which is why that case adds the row at top level instead.
+		blk := NewBlock(prefix)
+		blk.comment = from.comment
+		// Both are empty of rows that could clash, so neither call can fail.
+		_ = e.Add(blk)
+	}
+	_ = e.Add(r)
+}
+
+// hasPrefix reports whether any row of e carries prefix.
+func (e *Env) hasPrefix(prefix string) bool {
+	for r := range e.Rows() {
+		if p, _ := splitKey(r.key); p == prefix {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package envi_test
+
+import (
+	"testing"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+func TestSyncFrom(t *testing.T) {
+	t.Parallel()
+
+	const example = "###   ---[ Application ]---   ###\n" +
+		"# human readable name\nAPP_NAME=example\nAPP_PORT=8080 # http\n\n" +
+		"###   ---[ Database ]---   ###\n# where the database lives\nDB_HOST=localhost\nDB_PORT=5432\n\n" +
+		"# SENTRY_DSN=\nDEBUG=false\n"
+	const working = "# mine\nAPP_NAME=mine\n\nSTALE=1\n# OLD=2\n# SENTRY_DSN=https://x\n"
+
+	t.Run("adds what is missing and reports what is stale", func(t *testing.T) {
+		t.Parallel()
+
+		e := mustParse(t, working)
+		d := e.SyncFrom(mustParse(t, example), envi.SyncOptions{})
+
+		want := "- STALE=\"1\"\n+ APP_PORT=\"8080\"\n+ DB_HOST=\"localhost\"\n+ DB_PORT=\"5432\"\n+ DEBUG=\"false\"\n"
+		if got := d.String(); got != want {
+			t.Errorf("delta = %q, want %q", got, want)
+		}
+
+		// APP_PORT joins the APP block the parser made of the first rows.
+		wantDoc := "# mine\nAPP_NAME=mine\nAPP_PORT=8080 # http\n\nSTALE=1\n# OLD=2\n# SENTRY_DSN=https://x\n" +
+			"###   ---[ Database ]---   ###\n# where the database lives\nDB_HOST=localhost\nDB_PORT=5432\n\nDEBUG=false\n"
+		if got := e.String(); got != wantDoc {
+			t.Errorf("document =\n%s\nwant\n%s", got, wantDoc)
+		}
+	})
+
+	t.Run("never touches an existing value", func(t *testing.T) {
+		t.Parallel()
+
+		e := mustParse(t, "APP_NAME=mine\nAPP_PORT=1\nDB_HOST=h\nDB_PORT=2\nDEBUG=true\n# SENTRY_DSN=x\n")
+		before := e.String()
+		if d := e.SyncFrom(mustParse(t, example), envi.SyncOptions{Prune: true}); !d.Empty() {
+			t.Errorf("delta = %q, want nothing", d)
+		}
+		if e.String() != before {
+			t.Errorf("an in-sync document changed:\n%s", e)
+		}
+	})
+
+	t.Run("prune comments stale keys out", func(t *testing.T) {
+		t.Parallel()
+
+		e := mustParse(t, "A=1\nSTALE=2\n")
+		d := e.SyncFrom(mustParse(t, "A=x\n"), envi.SyncOptions{Prune: true})
+		if d.Count(envi.ChangeRemoved) != 1 {
+			t.Errorf("delta = %q", d)
+		}
+		if got, want := e.String(), "A=1\n# STALE=2\n"; got != want {
+			t.Errorf("document = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("joins an existing block", func(t *testing.T) {
+		t.Parallel()
+
+		e := mustParse(t, "DB_HOST=h\n\nOTHER=1\n")
+		e.SyncFrom(mustParse(t, "DB_HOST=x\nDB_PORT=5432\nOTHER=1\n"), envi.SyncOptions{})
+		if got, want := e.String(), "DB_HOST=h\nDB_PORT=5432\n\nOTHER=1\n"; got != want {
+			t.Errorf("document = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("nil template", func(t *testing.T) {
+		t.Parallel()
+
+		e := mustParse(t, "A=1\n")
+		if d := e.SyncFrom(nil, envi.SyncOptions{Prune: true}); !d.Empty() || e.String() != "A=1\n" {
+			t.Errorf("a nil template changed something: %q", e)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package envi
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Tree returns what the document configures as nested maps, the shape Helm
+// values and most JSON configuration take. A key is split on the block
+// separator and each part becomes a level, lower-cased:
+//
+//	APP_DB_HOST=db       {"app": {"db": {"host": "db", "port": "5432"}},
+//	APP_DB_PORT=5432      "servers": [{"host": "a"}, {"host": "b"}]}
+//	SERVERS_0_HOST=a
+//	SERVERS_1_HOST=b
+//
+// A level whose names are exactly 0, 1, 2 and so on becomes a []any, so that
+// indexed keys read as the array they stand for. Values stay strings; a
+// commented-out row configures nothing and is left out.
+//
+// depth caps how many times a key is split, 0 meaning no limit: at depth 1,
+// APP_DB_HOST is {"app": {"db_host": ...}}. An underscore at either end of a
+// key never splits it.
+//
+// A key that is a value and also the parent of another — APP_DB beside
+// APP_DB_HOST — cannot be both in a tree, and is an error naming the two rather
+// than one of them quietly lost. A smaller depth may avoid it. [FromTree] is the
+// inverse.
+func (e *Env) Tree(depth int) (map[string]any, error) {
+	root := map[string]any{}
+	for r := range e.Rows() {
+		if r.commented {
+			continue
+		}
+		path := treePath(r.key, depth)
+		node := root
+		for i, seg := range path[:len(path)-1] {
+			switch child := node[seg].(type) {
+			case nil:
+				next := map[string]any{}
+				node[seg] = next
+				node = next
+			case map[string]any:
+				node = child
+			default:
+				return nil, treeCollision(strings.ToUpper(strings.Join(path[:i+1], "_")), r.key)
+			}
+		}
+		last := path[len(path)-1]
+		if child, ok := node[last].(map[string]any); ok {
+			return nil, treeCollision(r.key, r.key+"_"+strings.ToUpper(anyLeaf(child)))
+		}
+		node[last] = r.value
+	}
+	for k, v := range root {
+		root[k] = arrays(v)
+	}
+	return root, nil
+}
+
+// FromTree builds a document from nested maps, the inverse of [Env.Tree]:
+// names are joined along the path with the block separator and normalised, so
+// {"app": {"db": {"host": "db"}}} becomes APP_DB_HOST in an APP block. A slice
+// becomes indexed keys, SERVERS_0_HOST and on.
+//
+// Values are strings, numbers, booleans or nil, which give an empty value, as
+// they come from decoding JSON or YAML. Anything else, and two paths arriving
+// at the same key, are errors naming the path.
+func FromTree(tree map[string]any) (*Env, error) {
+	return newFlattener("tree", true).build(tree)
+}
+
+// treePath splits a key into the names of its levels, lower-cased, splitting
+// at most depth times when depth is positive.
+func treePath(key string, depth int) []string {
+	var path []string
+	start := 0
+	for i := 1; i < len(key)-1; i++ {
+		if depth > 0 && len(path) == depth {
+			break
+		}
+		if key[i] == blockSeparator {
+			path = append(path, strings.ToLower(key[start:i]))
+			start = i + 1
+		}
+	}
+	return append(path, strings.ToLower(key[start:]))
+}
+
+// treeCollision reports that the value of key is also the parent of child.
+func treeCollision(key, child string) error {
+	return fmt.Errorf("envi: tree: %s and %s collide: %s cannot be a value and hold %s", key, child, key, child)
+}
+
+// anyLeaf returns the path below node to its first value in sorted order,
+// joined the way keys are, to name a key a collision involves.
+func anyLeaf(node map[string]any) string {
+	k := slices.Min(slices.Collect(maps.Keys(node)))
+	if child, ok := node[k].(map[string]any); ok {
+		return k + "_" + anyLeaf(child)
+	}
+	return k
+}
+
+// arrays turns every level of node whose names are 0 to n-1 into a []any.
+func arrays(node any) any {
+	m, ok := node.(map[string]any)
+	if !ok {
+		return node
+	}
+	for k, v := range m {
+		m[k] = arrays(v)
+	}
+	list := make([]any, len(m))
+	for k, v := range m {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || i >= len(m) || strconv.Itoa(i) != k {
+			return m
+		}
+		list[i] = v
+	}
+	return list
+}
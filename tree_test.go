@@ -0,0 +1,95 @@
+package envi_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+func TestTree(t *testing.T) {
+	t.Parallel()
+
+	e, err := envi.ParseString("APP_DB_HOST=db\nAPP_DB_PORT=5432\n# APP_DEBUG=true\nSERVERS_0_HOST=a\nSERVERS_1_HOST=b\nNAME=app\n_PRIVATE=x\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := e.Tree(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"app":      map[string]any{"db": map[string]any{"host": "db", "port": "5432"}},
+		"servers":  []any{map[string]any{"host": "a"}, map[string]any{"host": "b"}},
+		"name":     "app",
+		"_private": "x",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tree(0) = %v\nwant %v", got, want)
+	}
+
+	got, err = e.Tree(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app := got["app"]; !reflect.DeepEqual(app, map[string]any{"db_host": "db", "db_port": "5432"}) {
+		t.Errorf("Tree(1) app = %v", app)
+	}
+
+	back, err := envi.FromTree(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := back.String(), "APP_DB_HOST=db\nAPP_DB_PORT=5432\n\nNAME=app\nSERVERS_0_HOST=a\nSERVERS_1_HOST=b\n\n_PRIVATE=x\n"; got != want {
+		t.Errorf("FromTree gave\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTreeCollisions(t *testing.T) {
+	t.Parallel()
+
+	for src, msg := range map[string]string{
+		"APP_DB=x\nAPP_DB_HOST=y\n":                "APP_DB and APP_DB_HOST collide",
+		"APP_DB_HOST=y\nAPP_DB_PORT=1\nAPP_DB=x\n": "APP_DB and APP_DB_HOST collide",
+	} {
+		e, err := envi.ParseString(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := e.Tree(0); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%q: err = %v, want %q", src, err, msg)
+		}
+		// Split once, the two keys no longer meet.
+		if _, err := e.Tree(1); err != nil {
+			t.Errorf("%q at depth 1: %v", src, err)
+		}
+	}
+
+	var tree map[string]any
+	if err := json.Unmarshal([]byte(`{"db_host": "a", "db": {"host": "b"}}`), &tree); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := envi.FromTree(tree); err == nil || !strings.Contains(err.Error(), "envi: tree: db.host and db_host both become DB_HOST") {
+		t.Errorf("FromTree collision: err = %v", err)
+	}
+	if _, err := envi.FromTree(map[string]any{"k": struct{}{}}); err == nil {
+		t.Error("FromTree took a struct value")
+	}
+}
+
+func TestFromTreeScalars(t *testing.T) {
+	t.Parallel()
+
+	e, err := envi.FromTree(map[string]any{"port": 8080, "ratio": 1.5, "on": true, "none": nil, "big": float64(1e21)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{"PORT": "8080", "RATIO": "1.5", "ON": "true", "NONE": "", "BIG": "1000000000000000000000"} {
+		if got, _ := e.Lookup(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
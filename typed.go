@@ -0,0 +1,153 @@
+package envi
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/efureev/envi/v2/internal/convert"
+)
+
+// ErrNotSet reports a key the document has no value for, from one of the typed
+// accessors such as [GetAs]. It comes wrapped in a [*ValueError] naming the key.
+var ErrNotSet = errors.New("envi: key is not set")
+
+// ErrUnsupportedType reports a type [GetAs] cannot read a value into.
+var ErrUnsupportedType = errors.New("envi: unsupported type")
+
+// A ValueError reports a value a typed accessor could not read, or a key it
+// found no value for. Compare the cause with [errors.Is]: [ErrNotSet], or the
+// error of the parse that failed, such as [strconv.ErrSyntax].
+type ValueError struct {
+	// Key is the key that was read.
+	Key string
+
+	// Origin is where the value was stated, zero when the key is not set.
+	Origin Origin
+
+	// Type is the type the value was read as, such as "int". It is empty when
+	// the key is not set.
+	Type string
+
+	// Err is the cause.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ValueError) Error() string {
+	if errors.Is(e.Err, ErrNotSet) {
+		return "envi: " + e.Key + " is not set"
+	}
+	msg := "envi: " + e.Key
+	if !e.Origin.IsZero() {
+		msg += " (" + e.Origin.String() + ")"
+	}
+	return msg + ": not a valid " + e.Type + ": " + e.Err.Error()
+}
+
+// Unwrap returns the cause.
+func (e *ValueError) Unwrap() error { return e.Err }
+
+// defaultSeparator divides the elements of a slice and the entries of a map,
+// as it does in package bind.
+const defaultSeparator = ","
+
+// GetAs reads the value of key as a T, by the rules package bind fills a field
+// of type T with: a type implementing [encoding.TextUnmarshaler] reads itself,
+// a [time.Duration] is read by [time.ParseDuration], the numbers and bool by
+// [strconv], and slices and maps split on commas, each element read the same
+// way.
+//
+//	port, err := envi.GetAs[int](env, "APP_PORT")
+//	hosts, err := envi.GetAs[[]string](env, "HOSTS")
+//
+// A key the document lacks, or holds with an empty value, is a [*ValueError]
+// wrapping [ErrNotSet]: as in package bind, an empty value states that nothing
+// was configured. A value that does not read as a T is a [*ValueError] with the
+// row's [Origin], so the message points at the line to fix. Values are looked
+// up as [Env.Lookup] looks them up. Use [GetOr] for a key that may be left out.
+func GetAs[T any](e *Env, key string) (T, error) {
+	return getAs[T](e, key, defaultSeparator, nil)
+}
+
+// GetOr is [GetAs] for a key that may be left out: when the document holds no
+// value for key it returns def. A value that is present and does not read as a
+// T is still an error, returned along with def, so a mistake in the file is not
+// quietly replaced by the default.
+//
+//	port, err := envi.GetOr(env, "APP_PORT", 8080)
+func GetOr[T any](e *Env, key string, def T) (T, error) {
+	v, err := GetAs[T](e, key)
+	if err != nil {
+		if errors.Is(err, ErrNotSet) {
+			err = nil
+		}
+		return def, err
+	}
+	return v, nil
+}
+
+// getAs reads key as a T with the given separator and registered setters.
+func getAs[T any](e *Env, key, sep string, conv map[reflect.Type]convert.Setter) (T, error) {
+	var out T
+	t := reflect.TypeFor[T]()
+	set, err := convert.For(t, sep, conv)
+	var ue *convert.UnsupportedError
+	if errors.As(err, &ue) {
+		return out, fmt.Errorf("%w: %s", ErrUnsupportedType, ue)
+	}
+	if err != nil {
+		return out, err
+	}
+
+	k := NormalizeKey(key)
+	r := e.Get(k)
+	if r == nil || r.value == "" {
+		return out, &ValueError{Key: k, Err: ErrNotSet}
+	}
+	if err := set(reflect.ValueOf(&out).Elem(), r.value); err != nil {
+		var zero T
+		return zero, &ValueError{Key: k, Origin: r.origin, Type: t.String(), Err: err}
+	}
+	return out, nil
+}
+
+// Int reads the value of key as an int. See [GetAs].
+func (e *Env) Int(key string) (int, error) { return GetAs[int](e, key) }
+
+// Bool reads the value of key as a bool: 1, t, true, 0, f, false and their
+// upper-case forms, as [strconv.ParseBool] reads them. See [GetAs].
+func (e *Env) Bool(key string) (bool, error) { return GetAs[bool](e, key) }
+
+// Duration reads the value of key as a [time.Duration], "90s" or "1h30m". See
+// [GetAs].
+func (e *Env) Duration(key string) (time.Duration, error) { return GetAs[time.Duration](e, key) }
+
+// List reads the value of key as a list split on sep, each element trimmed of
+// surrounding space; an empty sep means a comma. See [GetAs].
+func (e *Env) List(key, sep string) ([]string, error) {
+	if sep == "" {
+		sep = defaultSeparator
+	}
+	return getAs[[]string](e, key, sep, nil)
+}
+
+// URL reads the value of key as a URL, by [url.Parse]: the converter package
+// bind is given for one. See [GetAs].
+func (e *Env) URL(key string) (*url.URL, error) {
+	return getAs[*url.URL](e, key, defaultSeparator, urlSetter)
+}
+
+// urlSetter reads a *url.URL, which has no text form of its own.
+var urlSetter = map[reflect.Type]convert.Setter{
+	reflect.TypeFor[*url.URL](): func(v reflect.Value, s string) error {
+		u, err := url.Parse(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(u))
+		return nil
+	},
+}
@@ -0,0 +1,103 @@
+package envi_test
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+const typedSrc = "PORT=8080\nDEBUG=true\nTTL=90s\nHOSTS=a, b ,c\nPATHS=/x:/y\nAPI=https://api.example.com/v1\nEMPTY=\nBAD_PORT=80a\nLIMITS=cpu:2,mem:4\n"
+
+func TestTypedAccessors(t *testing.T) {
+	t.Parallel()
+
+	e, err := envi.LoadWith(nil, writeEnvFile(t, t.TempDir(), ".env", typedSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := e.Int("port"); err != nil || n != 8080 {
+		t.Errorf("Int = %d, %v", n, err)
+	}
+	if b, err := e.Bool("DEBUG"); err != nil || !b {
+		t.Errorf("Bool = %v, %v", b, err)
+	}
+	if d, err := e.Duration("TTL"); err != nil || d != 90*time.Second {
+		t.Errorf("Duration = %v, %v", d, err)
+	}
+	if l, err := e.List("HOSTS", ""); err != nil || !slices.Equal(l, []string{"a", "b", "c"}) {
+		t.Errorf("List = %q, %v", l, err)
+	}
+	if l, err := e.List("PATHS", ":"); err != nil || !slices.Equal(l, []string{"/x", "/y"}) {
+		t.Errorf("List(:) = %q, %v", l, err)
+	}
+	if u, err := e.URL("API"); err != nil || u.Host != "api.example.com" || u.Path != "/v1" {
+		t.Errorf("URL = %v, %v", u, err)
+	}
+	if m, err := envi.GetAs[map[string]int](e, "LIMITS"); err != nil || m["cpu"] != 2 || m["mem"] != 4 {
+		t.Errorf("GetAs map = %v, %v", m, err)
+	}
+	if p, err := envi.GetAs[*uint16](e, "PORT"); err != nil || *p != 8080 {
+		t.Errorf("GetAs *uint16 = %v, %v", p, err)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	t.Parallel()
+
+	path := writeEnvFile(t, t.TempDir(), ".env", typedSrc)
+	e, err := envi.LoadWith(nil, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = e.Int("BAD_PORT")
+	var ve *envi.ValueError
+	if !errors.As(err, &ve) || ve.Key != "BAD_PORT" || ve.Origin.Line != 8 || ve.Origin.File != path || ve.Type != "int" {
+		t.Fatalf("err = %#v", err)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) || !strings.HasPrefix(err.Error(), "envi: BAD_PORT ("+path+":8): not a valid int: ") {
+		t.Errorf("err = %v", err)
+	}
+
+	for _, key := range []string{"MISSING", "EMPTY"} {
+		_, err := e.Duration(key)
+		if !errors.Is(err, envi.ErrNotSet) || !errors.As(err, &ve) || ve.Key != key || err.Error() != "envi: "+key+" is not set" {
+			t.Errorf("%s: err = %v", key, err)
+		}
+	}
+
+	if _, err := envi.GetAs[chan int](e, "PORT"); !errors.Is(err, envi.ErrUnsupportedType) {
+		t.Errorf("chan: err = %v", err)
+	}
+	if _, err := envi.GetAs[uint8](e, "PORT"); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("uint8: err = %v", err)
+	}
+}
+
+func TestGetOr(t *testing.T) {
+	t.Parallel()
+
+	e, err := envi.ParseString(typedSrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := envi.GetOr(e, "MISSING", 3000); err != nil || n != 3000 {
+		t.Errorf("missing = %d, %v", n, err)
+	}
+	if n, err := envi.GetOr(e, "EMPTY", 3000); err != nil || n != 3000 {
+		t.Errorf("empty = %d, %v", n, err)
+	}
+	if n, err := envi.GetOr(e, "PORT", 3000); err != nil || n != 8080 {
+		t.Errorf("set = %d, %v", n, err)
+	}
+	// A value that is wrong is not papered over by the default.
+	if n, err := envi.GetOr(e, "BAD_PORT", 3000); err == nil || n != 3000 {
+		t.Errorf("malformed = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,216 @@
+package envi
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Defaults for [WatchOptions].
+const (
+	defaultWatchInterval = time.Second
+	defaultWatchDebounce = 100 * time.Millisecond
+)
+
+// WatchOptions configures [Watch]. The zero value polls every second.
+type WatchOptions struct {
+	// Interval is how often the files are polled. Zero means one second.
+	Interval time.Duration
+
+	// Debounce is how long the files must stay unchanged before they are read
+	// again, so that an editor writing a file in several steps, or a deploy
+	// replacing several files, produces one reload rather than a burst of
+	// half-finished ones. Zero means 100ms.
+	Debounce time.Duration
+
+	// Optional names files read after the required ones if they exist, the
+	// way a .env.local is present on one machine and not on another. One that
+	// appears or disappears while watching is a change like any other.
+	Optional []string
+
+	// Options are passed to the parser.
+	Options []Option
+}
+
+// An Update is one reload delivered by [Watch].
+type Update struct {
+	// Env is the document as it now reads, the files merged the way [LoadWith]
+	// merges them. It is nil when Err is set.
+	Env *Env
+
+	// Delta is what changed in the configuration since the previous Env
+	// delivered — see [Env.Diff]. The first update compares against nothing,
+	// so it reports every configured key as added. An edit confined to
+	// comments or layout is still delivered, with an empty Delta.
+	Delta *Delta
+
+	// Err is why the files could not be read: one is missing, or no longer
+	// parses. The last good document stays the one the next Delta is taken
+	// against, so a broken edit followed by a fix reports only the fix.
+	Err error
+}
+
+// Watch reads the named files, and reads them again whenever they change,
+// until ctx is done.
+//
+// It polls rather than subscribing to the operating system, which keeps the
+// package free of dependencies and works the same on every platform and on
+// network and container filesystems where notification does not. A file is
+// only read again when its modification time or size moves, and a reload is
+// only delivered when the content itself differs, so touching a file, or
+// rewriting it with what it already held, is not a change.
+//
+// The files are read once before Watch returns, and a failure then is
+// returned rather than delivered. That first document arrives as the first
+// [Update]. The channel is closed once ctx is done; a consumer that stops
+// reading holds up polling rather than missing updates.
+func Watch(ctx context.Context, paths []string, opts WatchOptions) (<-chan Update, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultWatchInterval
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = defaultWatchDebounce
+	}
+
+	w := &watcher{paths: paths, opts: opts, states: make(map[string]fileState)}
+	w.changed() // record where every file stands before the first read
+	env, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan Update, 1)
+	out <- Update{Env: env, Delta: (*Env)(nil).Diff(env)}
+	go w.run(ctx, env, out)
+	return out, nil
+}
+
+// fileState is what polling remembers about one file.
+type fileState struct {
+	exists bool
+	mod    time.Time
+	size   int64
+	sum    [sha256.Size]byte
+}
+
+// watcher is the state of one [Watch] call.
+type watcher struct {
+	paths  []string
+	opts   WatchOptions
+	states map[string]fileState
+}
+
+func (w *watcher) run(ctx context.Context, last *Env, out chan<- Update) {
+	defer close(out)
+
+	tick := time.NewTicker(w.opts.Interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+		if !w.changed() {
+			continue
+		}
+		// Wait for the files to settle: every quiet period that still sees a
+		// change starts another.
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.opts.Debounce):
+			}
+			if !w.changed() {
+				break
+			}
+		}
+
+		var u Update
+		env, err := w.load()
+		if err != nil {
+			u.Err = err
+		} else {
+			u = Update{Env: env, Delta: last.Diff(env)}
+			last = env
+		}
+		select {
+		case out <- u:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// load reads the files the way [LoadWith] does, skipping optional ones that are
+// not there.
+func (w *watcher) load() (*Env, error) {
+	env := &Env{}
+	for _, path := range w.paths {
+		e, err := loadFile(path, w.opts.Options)
+		if err != nil {
+			return nil, err
+		}
+		if err := env.Merge(e); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range w.opts.Optional {
+		e, err := loadFile(path, w.opts.Options)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := env.Merge(e); err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+// changed polls every file and reports whether any of them now holds something
+// different from what was last seen, recording what it finds.
+func (w *watcher) changed() bool {
+	moved := false
+	for _, group := range [][]string{w.paths, w.opts.Optional} {
+		for _, path := range group {
+			if w.poll(path) {
+				moved = true
+			}
+		}
+	}
+	return moved
+}
+
+// poll checks one file. The content is hashed only when the file's metadata
+// has moved, so an idle poll costs one stat per file.
+func (w *watcher) poll(path string) bool {
+	prev, known := w.states[path]
+
+	info, err := os.Stat(path)
+	if err != nil {
+		w.states[path] = fileState{}
+		return known && prev.exists
+	}
+	if known && prev.exists && info.ModTime().Equal(prev.mod) && info.Size() == prev.size {
+		return false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// Gone between the stat and the read, or unreadable: either way the
+		// next load will say so.
+		w.states[path] = fileState{}
+		return known && prev.exists
+	}
+	next := fileState{exists: true, mod: info.ModTime(), size: info.Size(), sum: sha256.Sum256(data)}
+	w.states[path] = next
+	return !known || !prev.exists || next.sum != prev.sum
+}
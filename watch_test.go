@@ -0,0 +1,154 @@
+package envi_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	envi "github.com/efureev/envi/v2"
+)
+
+// fastWatch polls quickly enough for a test to see a change in milliseconds.
+var fastWatch = envi.WatchOptions{Interval: 5 * time.Millisecond, Debounce: 10 * time.Millisecond}
+
+// next waits for one update, failing the test if none arrives in time.
+func next(t *testing.T, updates <-chan envi.Update) envi.Update {
+	t.Helper()
+
+	select {
+	case u, ok := <-updates:
+		if !ok {
+			t.Fatal("the update channel closed")
+		}
+		return u
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update arrived")
+	}
+	return envi.Update{}
+}
+
+// stamps hands out distinct modification times, one per rewrite.
+var stamps atomic.Int64
+
+// rewrite replaces a file's content and gives it a modification time no other
+// write has had, so that a filesystem with coarse timestamps still sees it.
+func rewrite(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Duration(stamps.Add(1)) * time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchDeliversChanges(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := writeEnvFile(t, dir, ".env", "A=1\nB=2\n")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	updates, err := envi.Watch(ctx, []string{path}, fastWatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := next(t, updates)
+	if first.Err != nil || first.Delta.Count(envi.ChangeAdded) != 2 {
+		t.Fatalf("first update = %+v, want every key added", first)
+	}
+
+	rewrite(t, path, "A=1\nB=3\nC=4\n")
+	u := next(t, updates)
+	if u.Err != nil {
+		t.Fatal(u.Err)
+	}
+	if got := u.Delta.String(); got != "~ B: \"2\" -> \"3\"\n+ C=\"4\"\n" {
+		t.Errorf("delta = %q", got)
+	}
+	if v, _ := u.Env.Lookup("C"); v != "4" {
+		t.Errorf("C = %q, want 4", v)
+	}
+
+	// A broken edit is reported, and the fix after it is measured against the
+	// last good document rather than against nothing.
+	rewrite(t, path, "A=1\nbroken line\n")
+	if u := next(t, updates); u.Err == nil || u.Env != nil {
+		t.Errorf("broken edit = %+v, want an error", u)
+	}
+	rewrite(t, path, "A=2\nB=3\nC=4\n")
+	if u := next(t, updates); u.Err != nil || u.Delta.String() != "~ A: \"1\" -> \"2\"\n" {
+		t.Errorf("fix = %+v, delta %q", u, u.Delta)
+	}
+
+	cancel()
+	for range updates {
+		// Drain until the watcher closes the channel.
+	}
+}
+
+func TestWatchIgnoresTouchWithoutChange(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := writeEnvFile(t, dir, ".env", "A=1\n")
+
+	updates, err := envi.Watch(t.Context(), []string{path}, fastWatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next(t, updates)
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case u := <-updates:
+		t.Errorf("touching the file delivered %+v", u)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWatchOptionalFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	base := writeEnvFile(t, dir, ".env", "A=1\n")
+	local := filepath.Join(dir, ".env.local")
+
+	opts := fastWatch
+	opts.Optional = []string{local}
+	updates, err := envi.Watch(t.Context(), []string{base}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next(t, updates)
+
+	rewrite(t, local, "A=2\n")
+	if u := next(t, updates); u.Err != nil || u.Delta.String() != "~ A: \"1\" -> \"2\"\n" {
+		t.Errorf("optional file appearing = %+v", u)
+	}
+	if err := os.Remove(local); err != nil {
+		t.Fatal(err)
+	}
+	if u := next(t, updates); u.Err != nil || u.Delta.String() != "~ A: \"2\" -> \"1\"\n" {
+		t.Errorf("optional file disappearing = %+v", u)
+	}
+}
+
+func TestWatchFailsOnFirstRead(t *testing.T) {
+	t.Parallel()
+
+	_, err := envi.Watch(t.Context(), []string{filepath.Join(t.TempDir(), "nope")}, fastWatch)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("err = %v, want os.ErrNotExist", err)
+	}
+}